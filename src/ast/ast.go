@@ -0,0 +1,327 @@
+package ast
+
+import (
+	"strings"
+	"tokenizer"
+)
+
+// Node of the abstract syntax tree.
+// Pos returns the first token of the node.
+type Node interface {
+	Pos() tokenizer.Token
+}
+
+// Statement node.
+type Stmt interface {
+	Node
+	stmtNode()
+}
+
+// Expression node.
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// Types of build in function calls, see types package.
+const (
+	NullCall   = 1
+	UnaryCall  = 2
+	BinaryCall = 3
+)
+
+type (
+	// A compiled ASL file.
+	File struct {
+		Name     string
+		Stmts    []Stmt
+		Comments []*Comment // all comments in source order, if trivia is enabled on the lexer
+	}
+
+	// Statements enclosed in braces.
+	Block struct {
+		Token tokenizer.Token // {
+		Stmts []Stmt
+		End   tokenizer.Token // }, empty for blocks without braces like cases
+	}
+
+	// Preprocessor command like #define.
+	Preprocessor struct {
+		Token tokenizer.Token
+		Text  string
+	}
+
+	// Variable declaration: var name = value;
+	Var struct {
+		Token tokenizer.Token // var
+		Name  *Ident
+		Value Expr // nil if not assigned
+	}
+
+	// Assignment: name = value;
+	Assign struct {
+		Name  *Ident
+		Value Expr
+	}
+
+	// if cond {...} else {...}
+	If struct {
+		Token tokenizer.Token // if
+		Cond  Expr
+		Then  *Block
+		Else  *Block // nil if there is no else branch
+	}
+
+	// while cond {...}
+	While struct {
+		Token tokenizer.Token // while
+		Cond  Expr
+		Body  *Block
+	}
+
+	// for var init; cond; post {...}
+	For struct {
+		Token tokenizer.Token // for
+		Var   bool            // var keyword used before init
+		Init  Expr
+		Cond  Expr
+		Post  Expr
+		Body  *Block
+	}
+
+	// foreach elem => expr {...}
+	Foreach struct {
+		Token tokenizer.Token // foreach
+		Elem  *Ident
+		Expr  Expr
+		Body  *Block
+	}
+
+	// Function declaration: func name(params) {...}
+	// Inline is true for functions declared with inline func, which are copied into their calls.
+	Func struct {
+		Token  tokenizer.Token // func
+		Doc    *Comment        // doc comment /** ... */ in front of func, nil if there is none
+		Inline bool
+		Name   *Ident
+		Params []*Param
+		Body   *Block
+	}
+
+	// Function parameter with optional default value.
+	Param struct {
+		Name    *Ident
+		Default Expr // nil if there is no default value
+	}
+
+	// switch expr {case ...: ... default: ...}
+	Switch struct {
+		Token tokenizer.Token // switch
+		Expr  Expr
+		Cases []*Case
+	}
+
+	// Case within a switch statement.
+	Case struct {
+		Token tokenizer.Token // case or default
+		Expr  Expr            // nil for default
+		Body  *Block          // nil if the case has no statements
+	}
+
+	// return value;
+	Return struct {
+		Token tokenizer.Token // return
+		Value Expr
+	}
+
+	// try {...} catch {...}
+	Try struct {
+		Token tokenizer.Token // try
+		Body  *Block
+		Catch *Block
+	}
+
+	// exitwith {...}
+	ExitWith struct {
+		Token tokenizer.Token // exitwith
+		Body  *Block
+	}
+
+	// waituntil(expr; cond);
+	WaitUntil struct {
+		Token tokenizer.Token // waituntil
+		Exprs []Expr
+	}
+
+	// Expression used as statement, like a function call.
+	ExprStmt struct {
+		X Expr
+	}
+)
+
+type (
+	// Identifier like a variable or function name.
+	Ident struct {
+		Token tokenizer.Token
+		Name  string
+	}
+
+	// Number, string or boolean as written in source code.
+	Literal struct {
+		Token tokenizer.Token
+		Kind  tokenizer.Kind // tokenizer.Number, tokenizer.String or tokenizer.Keyword (true, false)
+		Value string
+	}
+
+	// Array declared in place: [elems]
+	Array struct {
+		Token tokenizer.Token // [
+		Elems []Expr
+	}
+
+	// Expression in parentheses.
+	Paren struct {
+		Token tokenizer.Token // (
+		X     Expr
+	}
+
+	// Unary operation: !x, -x, not x
+	Unary struct {
+		Token tokenizer.Token // operator
+		Op    string
+		X     Expr
+	}
+
+	// Binary operation. Op is the operator as written in ASL,
+	// like +, ==, &&, and, in or ===. Within for loops = is used for assignments.
+	Binary struct {
+		X       Expr
+		Op      string
+		OpToken tokenizer.Token
+		Y       Expr
+	}
+
+	// Array access: x[index]
+	Index struct {
+		X     Expr
+		Index Expr
+	}
+
+	// Call of a function declared in ASL: name(args)
+	Call struct {
+		Name *Ident
+		Args []Expr
+	}
+
+	// Call of a build in function. Type is one of NullCall, UnaryCall or BinaryCall.
+	// Arguments of unary and null calls are stored in Right.
+	// Method is true for calls written like left.name(right).
+	BuiltinCall struct {
+		Name   *Ident
+		Type   int
+		Left   []Expr
+		Right  []Expr
+		Method bool
+	}
+
+	// Inline code: code("...")
+	Code struct {
+		Token  tokenizer.Token // code
+		Source tokenizer.Token // string containing the code
+		Body   *Block
+	}
+
+	// Interpolated string: $"{_a} killed {_b}"
+	// Format is the format string with placeholders (%1, %2, ...) for Args.
+	Interpolation struct {
+		Token  tokenizer.Token
+		Format string
+		Args   []Expr
+	}
+)
+
+// Comment in source code: // ... or /* ... */
+// Comments are only read if trivia is enabled on the lexer.
+type Comment struct {
+	Token tokenizer.Token // position of the comment
+	Text  string
+}
+
+// Returns true for doc comments, starting with /**.
+func (c *Comment) Doc() bool {
+	return strings.HasPrefix(c.Text, "/**") && c.Text != "/**/"
+}
+
+func (n *File) Pos() tokenizer.Token {
+	if len(n.Stmts) > 0 {
+		return n.Stmts[0].Pos()
+	}
+
+	return tokenizer.Token{File: n.Name}
+}
+
+func (n *Comment) Pos() tokenizer.Token      { return n.Token }
+func (n *Block) Pos() tokenizer.Token        { return n.Token }
+func (n *Preprocessor) Pos() tokenizer.Token { return n.Token }
+func (n *Var) Pos() tokenizer.Token          { return n.Token }
+func (n *Assign) Pos() tokenizer.Token       { return n.Name.Pos() }
+func (n *If) Pos() tokenizer.Token           { return n.Token }
+func (n *While) Pos() tokenizer.Token        { return n.Token }
+func (n *For) Pos() tokenizer.Token          { return n.Token }
+func (n *Foreach) Pos() tokenizer.Token      { return n.Token }
+func (n *Func) Pos() tokenizer.Token         { return n.Token }
+func (n *Param) Pos() tokenizer.Token        { return n.Name.Pos() }
+func (n *Switch) Pos() tokenizer.Token       { return n.Token }
+func (n *Case) Pos() tokenizer.Token         { return n.Token }
+func (n *Return) Pos() tokenizer.Token       { return n.Token }
+func (n *Try) Pos() tokenizer.Token          { return n.Token }
+func (n *ExitWith) Pos() tokenizer.Token     { return n.Token }
+func (n *WaitUntil) Pos() tokenizer.Token    { return n.Token }
+func (n *ExprStmt) Pos() tokenizer.Token     { return n.X.Pos() }
+
+func (n *Ident) Pos() tokenizer.Token         { return n.Token }
+func (n *Literal) Pos() tokenizer.Token       { return n.Token }
+func (n *Array) Pos() tokenizer.Token         { return n.Token }
+func (n *Paren) Pos() tokenizer.Token         { return n.Token }
+func (n *Unary) Pos() tokenizer.Token         { return n.Token }
+func (n *Binary) Pos() tokenizer.Token        { return n.X.Pos() }
+func (n *Index) Pos() tokenizer.Token         { return n.X.Pos() }
+func (n *Call) Pos() tokenizer.Token          { return n.Name.Pos() }
+func (n *Code) Pos() tokenizer.Token          { return n.Token }
+func (n *Interpolation) Pos() tokenizer.Token { return n.Token }
+
+func (n *BuiltinCall) Pos() tokenizer.Token {
+	if n.Method && len(n.Left) > 0 {
+		return n.Left[0].Pos()
+	}
+
+	return n.Name.Pos()
+}
+
+func (*Block) stmtNode()        {}
+func (*Preprocessor) stmtNode() {}
+func (*Var) stmtNode()          {}
+func (*Assign) stmtNode()       {}
+func (*If) stmtNode()           {}
+func (*While) stmtNode()        {}
+func (*For) stmtNode()          {}
+func (*Foreach) stmtNode()      {}
+func (*Func) stmtNode()         {}
+func (*Switch) stmtNode()       {}
+func (*Return) stmtNode()       {}
+func (*Try) stmtNode()          {}
+func (*ExitWith) stmtNode()     {}
+func (*WaitUntil) stmtNode()    {}
+func (*ExprStmt) stmtNode()     {}
+
+func (*Ident) exprNode()         {}
+func (*Literal) exprNode()       {}
+func (*Array) exprNode()         {}
+func (*Paren) exprNode()         {}
+func (*Unary) exprNode()         {}
+func (*Binary) exprNode()        {}
+func (*Index) exprNode()         {}
+func (*Call) exprNode()          {}
+func (*BuiltinCall) exprNode()   {}
+func (*Code) exprNode()          {}
+func (*Interpolation) exprNode() {}
@@ -0,0 +1,101 @@
+package ast_test
+
+import (
+	"ast"
+	"os"
+	"parser"
+	"testing"
+	"tokenizer"
+)
+
+func TestASTStructure(t *testing.T) {
+	file := getAST(t, "../../test/parser_func_call.asl")
+
+	if len(file.Stmts) != 2 {
+		t.Fatal("File must contain function declaration and call")
+	}
+
+	function, ok := file.Stmts[0].(*ast.Func)
+
+	if !ok || function.Name.Name != "myFunc" || len(function.Params) != 2 || len(function.Body.Stmts) != 1 {
+		t.Fatal("First statement must be declaration of myFunc with two parameters")
+	}
+
+	if _, ok := function.Body.Stmts[0].(*ast.Return).Value.(*ast.Binary); !ok {
+		t.Error("Function must return binary expression")
+	}
+
+	call, ok := file.Stmts[1].(*ast.ExprStmt).X.(*ast.Call)
+
+	if !ok || call.Name.Name != "myFunc" || len(call.Args) != 2 || call.Pos().Line != 5 {
+		t.Error("Second statement must be call of myFunc with two arguments in line 5")
+	}
+}
+
+func TestASTInspect(t *testing.T) {
+	file := getAST(t, "../../test/parser_func_call.asl")
+	idents := make([]string, 0)
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok {
+			idents = append(idents, ident.Name)
+		}
+
+		// skip function bodies
+		_, ok := node.(*ast.Func)
+		return !ok
+	})
+
+	if len(idents) != 1 || idents[0] != "myFunc" {
+		t.Error("Inspect must only find the name of the called function, got:")
+		t.Log(idents)
+	}
+}
+
+func TestASTWalk(t *testing.T) {
+	v := &depthVisitor{}
+	ast.Walk(v, getAST(t, "../../test/tokenizer_switch.asl"))
+
+	if v.depth != 0 || v.max != 6 {
+		t.Error("Walk must visit all nodes and leave them again")
+		t.Log(v.depth, v.max)
+	}
+}
+
+type depthVisitor struct {
+	depth, max int
+}
+
+func (v *depthVisitor) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		v.depth--
+		return nil
+	}
+
+	v.depth++
+
+	if v.depth > v.max {
+		v.max = v.depth
+	}
+
+	return v
+}
+
+func getAST(t *testing.T, file string) *ast.File {
+	in, err := os.Open(file)
+
+	if err != nil {
+		t.Fatal("Could not read test file: " + file)
+	}
+
+	defer in.Close()
+	compiler := parser.Compiler{}
+
+	tree, diagnostics := compiler.ParseAST(tokenizer.NewLexer(in, file))
+
+	for _, d := range diagnostics {
+		t.Error(d)
+	}
+
+	return tree
+}
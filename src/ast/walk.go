@@ -0,0 +1,148 @@
+package ast
+
+// Visitor is called for each node by Walk.
+// If the returned visitor w is not nil, the children of the node are visited with w,
+// followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Traverses the syntax tree in depth-first order, starting with node.
+// Children are visited in source order.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *File:
+		walkStmts(v, n.Stmts)
+	case *Block:
+		walkStmts(v, n.Stmts)
+	case *Var:
+		Walk(v, n.Name)
+		walkExpr(v, n.Value)
+	case *Assign:
+		Walk(v, n.Name)
+		Walk(v, n.Value)
+	case *If:
+		Walk(v, n.Cond)
+		Walk(v, n.Then)
+
+		if n.Else != nil {
+			Walk(v, n.Else)
+		}
+	case *While:
+		Walk(v, n.Cond)
+		Walk(v, n.Body)
+	case *For:
+		Walk(v, n.Init)
+		Walk(v, n.Cond)
+		Walk(v, n.Post)
+		Walk(v, n.Body)
+	case *Foreach:
+		Walk(v, n.Elem)
+		Walk(v, n.Expr)
+		Walk(v, n.Body)
+	case *Func:
+		Walk(v, n.Name)
+
+		for _, param := range n.Params {
+			Walk(v, param)
+		}
+
+		Walk(v, n.Body)
+	case *Param:
+		Walk(v, n.Name)
+		walkExpr(v, n.Default)
+	case *Switch:
+		Walk(v, n.Expr)
+
+		for _, c := range n.Cases {
+			Walk(v, c)
+		}
+	case *Case:
+		walkExpr(v, n.Expr)
+
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *Return:
+		Walk(v, n.Value)
+	case *Try:
+		Walk(v, n.Body)
+		Walk(v, n.Catch)
+	case *ExitWith:
+		Walk(v, n.Body)
+	case *WaitUntil:
+		walkExprs(v, n.Exprs)
+	case *ExprStmt:
+		Walk(v, n.X)
+	case *Array:
+		walkExprs(v, n.Elems)
+	case *Paren:
+		Walk(v, n.X)
+	case *Unary:
+		Walk(v, n.X)
+	case *Binary:
+		Walk(v, n.X)
+		Walk(v, n.Y)
+	case *Index:
+		Walk(v, n.X)
+		Walk(v, n.Index)
+	case *Call:
+		Walk(v, n.Name)
+		walkExprs(v, n.Args)
+	case *BuiltinCall:
+		if n.Method {
+			walkExprs(v, n.Left)
+			Walk(v, n.Name)
+		} else {
+			Walk(v, n.Name)
+			walkExprs(v, n.Left)
+		}
+
+		walkExprs(v, n.Right)
+	case *Code:
+		Walk(v, n.Body)
+	case *Interpolation:
+		walkExprs(v, n.Args)
+	}
+
+	v.Visit(nil)
+}
+
+func walkStmts(v Visitor, stmts []Stmt) {
+	for _, stmt := range stmts {
+		Walk(v, stmt)
+	}
+}
+
+func walkExprs(v Visitor, exprs []Expr) {
+	for _, expr := range exprs {
+		Walk(v, expr)
+	}
+}
+
+// Walks optional expressions, which might be nil.
+func walkExpr(v Visitor, expr Expr) {
+	if expr != nil {
+		Walk(v, expr)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+
+	return nil
+}
+
+// Traverses the syntax tree in depth-first order like Walk and calls f for each node.
+// If f returns true, the children of the node are inspected, followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,74 @@
+package cst
+
+import (
+	"tokenizer"
+)
+
+// Kinds of nodes.
+const (
+	File         = "File"
+	Token        = "Token"
+	Preprocessor = "Preprocessor"
+	Var          = "Var"
+	If           = "If"
+	While        = "While"
+	Switch       = "Switch"
+	Case         = "Case"
+	For          = "For"
+	Foreach      = "Foreach"
+	Func         = "Func"
+	Return       = "Return"
+	Try          = "Try"
+	ExitWith     = "ExitWith"
+	WaitUntil    = "WaitUntil"
+	Statement    = "Statement"
+	Expression   = "Expression"
+	Call         = "Call"
+	Array        = "Array"
+)
+
+// Node of a lossless concrete syntax tree.
+// Leafs are of kind Token and hold a token including its trivia,
+// all other nodes hold their children in source order.
+type Node struct {
+	Kind     string
+	Token    *tokenizer.Token
+	Children []*Node
+}
+
+// Creates a new leaf for given token.
+func NewLeaf(token tokenizer.Token) *Node {
+	return &Node{Kind: Token, Token: &token}
+}
+
+// Adds a child node.
+func (n *Node) Add(child *Node) {
+	n.Children = append(n.Children, child)
+}
+
+// Returns all tokens within the node in source order.
+func (n *Node) Tokens() []tokenizer.Token {
+	if n.Token != nil {
+		return []tokenizer.Token{*n.Token}
+	}
+
+	tokens := make([]tokenizer.Token, 0)
+
+	for _, child := range n.Children {
+		tokens = append(tokens, child.Tokens()...)
+	}
+
+	return tokens
+}
+
+// Returns the source code of the node.
+// If trivia was enabled when parsing, this is exactly the input.
+func (n *Node) String() string {
+	str := ""
+
+	for _, token := range n.Tokens() {
+		str += token.Source()
+	}
+
+	return str
+}
@@ -0,0 +1,118 @@
+package diagnostic
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Severity of a diagnostic.
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Info
+)
+
+// Codes identifying the kind of problem.
+const (
+	UnterminatedString   = "E001"
+	MalformedNumber      = "E002"
+	ExpectedToken        = "E003"
+	UnexpectedToken      = "E004"
+	UnexpectedEnd        = "E005"
+	BuildinRedeclared    = "E006"
+	NotBinaryBuildin     = "E007"
+	MissingParameter     = "E008"
+	InvalidInterpolation = "E009"
+	IOError              = "E010"
+	InternalError        = "E011"
+	NotGenerated         = "E012"
+	InvalidIdentifier    = "E013"
+	InvalidFlag          = "E014"
+	UnknownFunction      = "W001"
+	NotInlined           = "W002"
+	BuildinSpelling      = "W003"
+	RemovedFunction      = "I001"
+)
+
+// Compile status of files.
+const (
+	StatusOk    = "ok"
+	StatusError = "error"
+)
+
+var severityNames = []string{
+	"error",
+	"warning",
+	"info"}
+
+// Position within source code.
+// Line and Column start at 1, columns are counted in characters and Offset in bytes.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// Range of source code, End is the position right after the last character.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Problem found in source code.
+// Suggestions are possible replacements for the code within the range.
+type Diagnostic struct {
+	Severity    Severity `json:"severity"`
+	File        string   `json:"file"`
+	Range       Range    `json:"range"`
+	Message     string   `json:"message"`
+	Code        string   `json:"code"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// Result of compiling a single file, printed as one line of JSON for -format=json.
+type Result struct {
+	Input       string       `json:"input"`
+	Output      string       `json:"output"`
+	Status      string       `json:"status"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Returns the name of the severity.
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// Writes the severity by name.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(s.String())), nil
+}
+
+// Returns the message including the position, so that diagnostics can be used as errors.
+func (d Diagnostic) Error() string {
+	return d.Message + " in line " + strconv.Itoa(d.Range.Start.Line) + " at " + strconv.Itoa(d.Range.Start.Column)
+}
+
+// Returns true if any of the diagnostics is an error.
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == Error {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sorts diagnostics by file and position.
+func Sort(diagnostics []Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].File != diagnostics[j].File {
+			return diagnostics[i].File < diagnostics[j].File
+		}
+
+		return diagnostics[i].Range.Start.Offset < diagnostics[j].Range.Start.Offset
+	})
+}
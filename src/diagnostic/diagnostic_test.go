@@ -0,0 +1,87 @@
+package diagnostic_test
+
+import (
+	"diagnostic"
+	"encoding/json"
+	"testing"
+)
+
+func TestDiagnosticError(t *testing.T) {
+	d := diagnostic.Diagnostic{
+		Range:   diagnostic.Range{Start: diagnostic.Position{Line: 3, Column: 7}},
+		Message: "Parse error, expected ';' but was 'x'"}
+
+	if d.Error() != "Parse error, expected ';' but was 'x' in line 3 at 7" {
+		t.Error("Unexpected error message: " + d.Error())
+	}
+}
+
+func TestDiagnosticSort(t *testing.T) {
+	diagnostics := []diagnostic.Diagnostic{
+		{File: "b.asl", Severity: diagnostic.Warning},
+		{File: "a.asl", Severity: diagnostic.Warning, Range: diagnostic.Range{Start: diagnostic.Position{Offset: 10}}},
+		{File: "a.asl", Severity: diagnostic.Error, Range: diagnostic.Range{Start: diagnostic.Position{Offset: 2}}}}
+	diagnostic.Sort(diagnostics)
+
+	if diagnostics[0].Severity != diagnostic.Error || diagnostics[2].File != "b.asl" {
+		t.Error("Diagnostics must be sorted by file and position")
+	}
+
+	if !diagnostic.HasErrors(diagnostics) || diagnostic.HasErrors(diagnostics[1:]) {
+		t.Error("Only diagnostics of severity error are errors")
+	}
+}
+
+func TestDiagnosticRender(t *testing.T) {
+	source := []byte("var a = 1;\n\tx = hnit(a);\n")
+	d := diagnostic.Diagnostic{
+		Severity:    diagnostic.Warning,
+		File:        "test.asl",
+		Range:       diagnostic.Range{Start: diagnostic.Position{Line: 2, Column: 6}, End: diagnostic.Position{Line: 2, Column: 10}},
+		Message:     "Unknown function 'hnit'",
+		Code:        diagnostic.UnknownFunction,
+		Suggestions: []string{"hint"}}
+	want := "test.asl:2:6: warning[W001]: Unknown function 'hnit'\n 2 | \tx = hnit(a);\n   | \t    ^^^^\n   = did you mean 'hint'?\n"
+
+	if got := diagnostic.Render(d, source, false); got != want {
+		t.Error("Unexpected output, got:")
+		t.Log(got)
+	}
+
+	if got := diagnostic.Render(d, source, true); got == want || got[0] != '\x1b' {
+		t.Error("Colored output must contain escape codes")
+	}
+}
+
+func TestDiagnosticRenderWithoutFile(t *testing.T) {
+	d := diagnostic.Diagnostic{Severity: diagnostic.Error, Message: "Invalid number: x", Code: diagnostic.InvalidFlag}
+
+	if got := diagnostic.Render(d, nil, false); got != "error[E014]: Invalid number: x\n" {
+		t.Error("Problems without file must be rendered without location, got: " + got)
+	}
+}
+
+func TestDiagnosticRenderStartup(t *testing.T) {
+	d := []diagnostic.Diagnostic{{Severity: diagnostic.Error, File: "types", Message: "No 'types' file provided.", Code: diagnostic.IOError}}
+
+	if got := diagnostic.RenderStartup(d, false, false); got != "types: error[E010]: No 'types' file provided.\n" {
+		t.Error("Unexpected text, got: " + got)
+	}
+
+	want := `{"input":"","output":"","status":"error","diagnostics":[{"severity":"error","file":"types",` +
+		`"range":{"start":{"line":0,"column":0,"offset":0},"end":{"line":0,"column":0,"offset":0}},"message":"No 'types' file provided.","code":"E010"}]}` + "\n"
+
+	if got := diagnostic.RenderStartup(d, true, false); got != want {
+		t.Error("Startup errors must be written as result with error status, got: " + got)
+	}
+}
+
+func TestDiagnosticJSON(t *testing.T) {
+	d := diagnostic.Diagnostic{Severity: diagnostic.Warning, File: "test.asl", Message: "Unknown function 'hnit'", Code: diagnostic.UnknownFunction}
+	out, err := json.Marshal(d)
+	want := `{"severity":"warning","file":"test.asl","range":{"start":{"line":0,"column":0,"offset":0},"end":{"line":0,"column":0,"offset":0}},"message":"Unknown function 'hnit'","code":"W001"}`
+
+	if err != nil || string(out) != want {
+		t.Error("Unexpected JSON, got: " + string(out))
+	}
+}
@@ -0,0 +1,157 @@
+package diagnostic
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// ANSI escape codes used for colored output.
+const (
+	reset  = "\x1b[0m"
+	bold   = "\x1b[1m"
+	red    = "\x1b[31m"
+	yellow = "\x1b[33m"
+	blue   = "\x1b[34m"
+	cyan   = "\x1b[36m"
+)
+
+var severityColors = []string{red, yellow, cyan}
+
+// Returns the diagnostic as printed by the compiler:
+//
+//	file.asl:3:7: error[E003]: Parse error, expected ';' but was 'x'
+//	  3 | var a = 1 x
+//	    |           ^
+//	    = did you mean 'y'?
+//
+// The source code of the file is used to show the line of the diagnostic, it can be nil.
+// If color is true, the output contains ANSI escape codes.
+func Render(d Diagnostic, source []byte, color bool) string {
+	paint := func(str, code string) string {
+		if !color {
+			return str
+		}
+
+		return code + str + reset
+	}
+
+	severityColor := severityColors[d.Severity]
+	out := ""
+
+	// problems not related to source code have no position, like read errors, and invalid flags have no file
+	if d.File != "" {
+		location := d.File + ":"
+
+		if d.Range.Start.Line > 0 {
+			location += strconv.Itoa(d.Range.Start.Line) + ":" + strconv.Itoa(d.Range.Start.Column) + ":"
+		}
+
+		out = paint(location, bold) + " "
+	}
+
+	out += paint(d.Severity.String()+"["+d.Code+"]:", bold+severityColor) + " " + paint(d.Message, bold) + "\n"
+
+	line, ok := sourceLine(source, d.Range.Start.Line)
+
+	if ok {
+		number := strconv.Itoa(d.Range.Start.Line)
+		margin := strings.Repeat(" ", len(number)+1)
+		out += paint(" "+number+" |", blue) + " " + line + "\n"
+		out += paint(margin+" |", blue) + " " + paint(underline(line, d.Range), bold+severityColor) + "\n"
+
+		if len(d.Suggestions) > 0 {
+			out += paint(margin+" =", blue) + " did you mean " + quoteAll(d.Suggestions) + "?\n"
+		}
+	} else if len(d.Suggestions) > 0 {
+		out += "  = did you mean " + quoteAll(d.Suggestions) + "?\n"
+	}
+
+	return out
+}
+
+// Returns problems found before compiling, like invalid flags or missing files, as printed by the compiler.
+// As JSON, they are written as a single result with error status, but without input and output.
+func RenderStartup(diagnostics []Diagnostic, asJSON, color bool) string {
+	if asJSON {
+		out, _ := json.Marshal(Result{Status: StatusError, Diagnostics: diagnostics})
+		return string(out) + "\n"
+	}
+
+	out := ""
+
+	for _, d := range diagnostics {
+		out += Render(d, nil, color)
+	}
+
+	return out
+}
+
+// Returns the line of source code, counted from 1, without line break.
+func sourceLine(source []byte, line int) (string, bool) {
+	if source == nil || line < 1 {
+		return "", false
+	}
+
+	lines := strings.Split(string(source), "\n")
+
+	if line > len(lines) {
+		return "", false
+	}
+
+	return strings.TrimRight(lines[line-1], "\r"), true
+}
+
+// Returns carets under the range within the line, tabs are kept so that the carets line up.
+// Ranges reaching into following lines are underlined to the end of the line.
+func underline(line string, r Range) string {
+	out := ""
+	column := 1
+	end := r.End.Column
+
+	if r.End.Line > r.Start.Line || end <= r.Start.Column {
+		end = r.Start.Column + 1
+	}
+
+	if r.End.Line > r.Start.Line {
+		end = len([]rune(line)) + 1
+	}
+
+	for _, c := range line {
+		if column >= r.Start.Column {
+			break
+		}
+
+		if c == '\t' {
+			out += "\t"
+		} else {
+			out += " "
+		}
+
+		column++
+	}
+
+	// the range can start behind the last character, like the end of file
+	out += strings.Repeat(" ", maxInt(r.Start.Column-column, 0))
+
+	return out + strings.Repeat("^", maxInt(end-r.Start.Column, 1))
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+// Returns the strings quoted and separated by "or".
+func quoteAll(strs []string) string {
+	quoted := make([]string, 0, len(strs))
+
+	for _, str := range strs {
+		quoted = append(quoted, "'"+str+"'")
+	}
+
+	return strings.Join(quoted, " or ")
+}
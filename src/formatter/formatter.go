@@ -0,0 +1,343 @@
+package formatter
+
+import (
+	"bytes"
+	"cst"
+	"diagnostic"
+	"parser"
+	"strings"
+	"tokenizer"
+)
+
+const indentation = "    "
+
+var bom = []byte{0xEF, 0xBB, 0xBF}
+
+// operators made of multiple operator tokens, written without space in between
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "===", "!==", "=>"}
+
+// Token of the syntax tree together with the kind of its parent node.
+type leaf struct {
+	tokenizer.Token
+	parent   string
+	caseEnds int // number of cases ending with this token
+}
+
+type formatter struct {
+	leafs     []leaf
+	out       bytes.Buffer
+	newLine   string
+	indent    int
+	parens    int  // depth of ( and [
+	lines     int  // line breaks to write in front of the next token, 2 for a blank line
+	space     bool // write a space in front of the next token
+	lineStart bool // nothing written to the current line yet
+	stmtStart bool // next token starts a statement, otherwise new lines are indented by one more level
+	blockOpen bool // nothing written since the beginning of a block or case
+}
+
+// Returns the ASL source code in canonical layout.
+// Statements are written on lines of their own, blocks and cases are indented by four spaces,
+// binary operators are surrounded by spaces and multiple blank lines are joined.
+// Comments are kept. Line breaks are written as CRLF if the code contains any, otherwise as LF.
+// If the code contains errors, it is returned unchanged together with the diagnostics.
+func Format(code []byte, file string) ([]byte, []diagnostic.Diagnostic) {
+	lexer := tokenizer.NewLexer(bytes.NewReader(code), file)
+	lexer.EnableTrivia()
+	compiler := parser.Compiler{}
+	tree, diagnostics := compiler.ParseTree(lexer)
+
+	if diagnostic.HasErrors(diagnostics) {
+		return code, diagnostics
+	}
+
+	f := formatter{newLine: "\n", lineStart: true, stmtStart: true, blockOpen: true}
+
+	if bytes.Contains(code, []byte("\r\n")) {
+		f.newLine = "\r\n"
+	}
+
+	f.flatten(tree)
+	f.leafs = joinOperators(f.leafs)
+	f.format()
+
+	// the byte order mark is kept
+	if bytes.HasPrefix(code, bom) {
+		return append(bom, f.out.Bytes()...), diagnostics
+	}
+
+	return f.out.Bytes(), diagnostics
+}
+
+// Collects the tokens of the syntax tree in source order.
+func (f *formatter) flatten(node *cst.Node) {
+	for _, child := range node.Children {
+		if child.Token != nil {
+			f.leafs = append(f.leafs, leaf{Token: *child.Token, parent: node.Kind})
+		} else {
+			f.flatten(child)
+		}
+	}
+
+	if node.Kind == cst.Case && len(f.leafs) > 0 {
+		f.leafs[len(f.leafs)-1].caseEnds++
+	}
+}
+
+// Joins operator tokens forming a single operator like ==, if there is no whitespace in between.
+func joinOperators(leafs []leaf) []leaf {
+	joined := make([]leaf, 0, len(leafs))
+
+	for _, l := range leafs {
+		if n := len(joined); n > 0 {
+			prev := &joined[n-1]
+
+			if prev.Kind == tokenizer.Operator && l.Kind == tokenizer.Operator && prev.End == l.Start &&
+				len(prev.Trailing) == 0 && isOperator(prev.Token.Token+l.Token.Token) {
+				prev.Token.Token += l.Token.Token
+				prev.End = l.End
+				prev.Trailing = l.Trailing
+				prev.caseEnds += l.caseEnds
+				continue
+			}
+		}
+
+		joined = append(joined, l)
+	}
+
+	return joined
+}
+
+func (f *formatter) format() {
+	for i, l := range f.leafs {
+		f.before(i)
+
+		if l.Kind == tokenizer.EOF {
+			break
+		}
+
+		f.write(l.Token.Token, l.Kind == tokenizer.Preprocessor)
+		f.trailing(l)
+		f.after(i)
+	}
+
+	if !f.lineStart {
+		f.out.WriteString(f.newLine)
+	}
+}
+
+// Writes the comments in front of a token and decides how it is separated from the previous one.
+func (f *formatter) before(i int) {
+	l := f.leafs[i]
+
+	if (isToken(l, "case") || isToken(l, "default")) && l.parent == cst.Case {
+		f.breakStatement()
+	}
+
+	f.leading(l)
+
+	if isToken(l, "}") {
+		f.indent--
+		f.lines = 1
+		f.stmtStart = true
+	} else if l.Kind == tokenizer.Preprocessor {
+		f.breakStatement()
+	} else if i > 0 && f.lines == 0 && f.spaced(i) {
+		f.space = true
+	}
+}
+
+// Decides how a token is separated from the next one.
+func (f *formatter) after(i int) {
+	l := f.leafs[i]
+
+	switch {
+	case l.Kind == tokenizer.Preprocessor:
+		f.breakStatement()
+	case isToken(l, "(") || isToken(l, "["):
+		f.parens++
+	case isToken(l, ")") || isToken(l, "]"):
+		f.parens--
+	case isToken(l, "{"):
+		f.indent++
+		f.breakStatement()
+		f.blockOpen = true
+	case isToken(l, "}"):
+		// else and catch continue the statement on the same line, unless a comment is in between
+		f.stmtStart = true
+
+		if i+1 == len(f.leafs) || !(isToken(f.leafs[i+1], "else") || isToken(f.leafs[i+1], "catch") || isToken(f.leafs[i+1], ";")) {
+			f.breakLine(1)
+		}
+	case isToken(l, ";"):
+		// semicolons separate expressions within for and waituntil
+		if l.parent != cst.For && f.parens == 0 {
+			f.breakStatement()
+		}
+	case isToken(l, ":") && l.parent == cst.Case:
+		f.indent++
+		f.breakStatement()
+		f.blockOpen = true
+	}
+
+	f.indent -= l.caseEnds
+}
+
+// Writes the comments in front of a token on lines of their own.
+// Leading trivia starts at the beginning of a line, since trailing trivia reaches to the end of the line.
+// A blank line in front of a statement or between comments is kept, unless it starts a block.
+func (f *formatter) leading(l leaf) {
+	newLines := 0
+	comment := ""
+
+	for _, trivia := range l.Leading {
+		switch trivia.Kind {
+		case tokenizer.Newline:
+			newLines++
+		case tokenizer.Comment:
+			// after a comment, the first new line ends its line
+			f.blankLine(comment == "" && newLines > 0 || newLines > 1)
+			f.breakLine(1)
+			f.write(trivia.Text, false)
+			comment = trivia.Text
+			newLines = 0
+		}
+	}
+
+	if comment == "" {
+		// else and catch do not start a statement
+		f.blankLine(newLines > 0 && !isToken(l, "else") && !isToken(l, "catch"))
+	} else if newLines == 0 && !isLineComment(comment) {
+		// block comment in front of the token on the same line
+		f.space = true
+	} else {
+		f.blankLine(newLines > 1)
+		f.breakLine(1)
+	}
+}
+
+// Writes the comments behind a token on the same line.
+func (f *formatter) trailing(l leaf) {
+	for _, trivia := range l.Trailing {
+		if trivia.Kind != tokenizer.Comment {
+			continue
+		}
+
+		f.space = true
+		f.write(trivia.Text, false)
+		f.space = true
+
+		if isLineComment(trivia.Text) {
+			f.breakLine(1)
+		}
+	}
+}
+
+// Requests a blank line in front of the next token, if it starts a statement.
+func (f *formatter) blankLine(blank bool) {
+	if blank && f.stmtStart && !f.blockOpen {
+		f.breakLine(2)
+	}
+}
+
+// Requests given number of line breaks in front of the next token.
+func (f *formatter) breakLine(lines int) {
+	if f.lines < lines {
+		f.lines = lines
+	}
+}
+
+// Ends the current statement, the next token starts a new line.
+func (f *formatter) breakStatement() {
+	f.breakLine(1)
+	f.stmtStart = true
+}
+
+// Writes text separated from the previous one as requested.
+// Preprocessor commands are not indented.
+func (f *formatter) write(text string, preprocessor bool) {
+	if f.lines > 0 && f.out.Len() > 0 {
+		f.out.WriteString(strings.Repeat(f.newLine, f.lines))
+		f.lineStart = true
+	}
+
+	if f.lineStart && !preprocessor {
+		indent := f.indent
+
+		if !f.stmtStart {
+			indent++
+		}
+
+		if indent < 0 {
+			indent = 0
+		}
+
+		f.out.WriteString(strings.Repeat(indentation, indent))
+	} else if f.space && !f.lineStart {
+		f.out.WriteByte(' ')
+	}
+
+	f.out.WriteString(text)
+	f.lines = 0
+	f.space = false
+	f.lineStart = false
+	f.blockOpen = false
+
+	if !isComment(text) {
+		f.stmtStart = false
+	}
+}
+
+// Returns true if the token at given index is separated from the previous one by a space.
+func (f *formatter) spaced(i int) bool {
+	prev, l := f.leafs[i-1], f.leafs[i]
+
+	switch {
+	case isToken(l, ")"), isToken(l, "]"), isToken(l, ","), isToken(l, ";"), isToken(l, ":"), isToken(l, "."):
+		return false
+	case isToken(prev, "("), isToken(prev, "["), isToken(prev, "."):
+		return false
+	case isToken(prev, "!"), isToken(prev, "-") && f.isUnary(i-1):
+		return false
+	case isToken(l, "("), isToken(l, "["):
+		// calls and array access
+		return !(prev.Kind == tokenizer.Identifier || isToken(prev, ")") || isToken(prev, "]") ||
+			isToken(prev, "code") || isToken(prev, "waituntil"))
+	}
+
+	return true
+}
+
+// Returns true if the operator at given index is unary, which is the case if it does not follow an operand.
+func (f *formatter) isUnary(i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	prev := f.leafs[i-1]
+	return !(prev.Kind == tokenizer.Identifier || prev.Kind == tokenizer.Number || prev.Kind == tokenizer.String ||
+		isToken(prev, ")") || isToken(prev, "]") || isToken(prev, "true") || isToken(prev, "false"))
+}
+
+func isToken(l leaf, token string) bool {
+	return l.Token.Token == token && l.Kind != tokenizer.String
+}
+
+// Returns true if op is an operator made of multiple tokens, or the beginning of one.
+func isOperator(op string) bool {
+	for _, operator := range operators {
+		if strings.HasPrefix(operator, op) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isComment(text string) bool {
+	return strings.HasPrefix(text, "//") || strings.HasPrefix(text, "/*")
+}
+
+func isLineComment(text string) bool {
+	return strings.HasPrefix(text, "//")
+}
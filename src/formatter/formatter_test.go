@@ -0,0 +1,82 @@
+package formatter_test
+
+import (
+	"bytes"
+	"diagnostic"
+	"formatter"
+	"io/ioutil"
+	"parser"
+	"path/filepath"
+	"testing"
+	"tokenizer"
+	"types"
+)
+
+const (
+	types_file = "../../test/types"
+)
+
+func TestFormat(t *testing.T) {
+	in := "// comment\nvar x=-1 ;\n\n\n\nif x<=0&&!foo(x , [1,2]){hint( x );}else{\n// nothing\n}\n" +
+		"switch x {\ncase 1:\nx=a.getVariable(\"y\"); /* done */\ndefault:\n}\nfor var i=0;i<10;i=i+1 {}\nwaituntil(x=x+1;x>10);"
+	want := "// comment\nvar x = -1;\n\nif x <= 0 && !foo(x, [1, 2]) {\n    hint(x);\n} else {\n    // nothing\n}\n" +
+		"switch x {\n    case 1:\n        x = a.getVariable(\"y\"); /* done */\n    default:\n}\nfor var i = 0; i < 10; i = i + 1 {\n}\nwaituntil(x = x + 1; x > 10);\n"
+	types.LoadTypes(types_file)
+	got, diagnostics := formatter.Format([]byte(in), "")
+
+	if len(diagnostics) != 0 {
+		t.Fatal("Unexpected diagnostics:", diagnostics)
+	}
+
+	equal(t, string(got), want)
+}
+
+func TestFormatErrors(t *testing.T) {
+	in := []byte("var x = ;")
+	got, diagnostics := formatter.Format(in, "")
+
+	if len(diagnostics) == 0 || !bytes.Equal(got, in) {
+		t.Error("Code containing errors must not be formatted")
+	}
+}
+
+// Formatting must be idempotent and must not change the compiled code.
+func TestFormatFixtures(t *testing.T) {
+	types.LoadTypes(types_file)
+	files, _ := filepath.Glob("../../test/*.asl")
+
+	for _, file := range files {
+		code, _ := ioutil.ReadFile(file)
+		formatted, diagnostics := formatter.Format(code, file)
+
+		if diagnostic.HasErrors(diagnostics) {
+			continue
+		}
+
+		again, _ := formatter.Format(formatted, file)
+
+		if !bytes.Equal(formatted, again) {
+			t.Error("Formatting is not idempotent for " + file)
+		}
+
+		if compile(code) != compile(formatted) {
+			t.Error("Formatting changed compiled code of " + file)
+		}
+	}
+}
+
+func compile(code []byte) string {
+	compiler := parser.Compiler{}
+	out, _ := compiler.ParseLexer(tokenizer.NewLexer(bytes.NewReader(code), ""), false)
+	return out
+}
+
+func equal(t *testing.T, got, want string) {
+	if got != want {
+		t.Error("Results do not equal, got:")
+		t.Log(got)
+		t.Log("expected:")
+		t.Log(want)
+		t.FailNow()
+	}
+}
@@ -1,22 +1,43 @@
 package main
 
 import (
+	"ast"
+	"bytes"
+	"diagnostic"
+	"encoding/json"
 	"fmt"
+	"formatter"
 	"io/ioutil"
+	"optimizer"
 	"os"
 	"parser"
 	"path/filepath"
+	"rpt"
+	"sourcemap"
+	"sqf"
+	"strconv"
 	"strings"
 	"tokenizer"
 	"types"
 )
 
 const (
-	version       = "1.2.2"
+	version       = "1.3.0"
 	extension     = ".asl"
 	sqfextension  = ".sqf"
+	mapextension  = ".map"
 	typeinfo      = "types"
 	PathSeparator = string(os.PathSeparator)
+
+	// output formats
+	formatText = "text"
+	formatJSON = "json"
+
+	// line width at which pretty printing wraps lists
+	defaultMaxWidth = 100
+
+	// beginning of the header of generated SQF files, followed by the version and source file
+	generatedStamp = "/* Generated by asl "
 )
 
 type ASLFile struct {
@@ -26,21 +47,50 @@ type ASLFile struct {
 }
 
 var (
-	recursive bool = false
-	pretty    bool = false
-	exit      bool = false
-	aslFiles  []ASLFile
-	inDir     string
+	recursive   bool = false
+	force       bool = false
+	prune       bool = false
+	entries     []string
+	optimize         = optimizer.None
+	options          = sqf.Options{MaxWidth: defaultMaxWidth}
+	sourceMap   bool = false
+	exit        bool = false
+	failed      bool = false
+	format           = formatText
+	aslFiles    []ASLFile
+	startErrors []diagnostic.Diagnostic // problems found before compiling, like invalid flags
+	inDir       string
+	bom         = []byte{0xEF, 0xBB, 0xBF}
 )
 
 func usage() {
-	fmt.Println("Usage: asl [-v|-r|-pretty|--help] <input directory> <output directory>\n")
+	fmt.Print("Usage: asl [-v|-r|-pretty|-indent=4|-tabs|-newline=crlf|lf|-maxwidth=100|-preservelines|-minify|-keep-comments|-force|-O0|-O1|-O2|-prune|-entry=fn1,fn2|-sourcemap|-format=text|json|--help] <input directory> <output directory>\n\n")
 	fmt.Println("-v (optional) shows asl version")
 	fmt.Println("-r (optional) recursivly compile all asl files in folder")
-	fmt.Println("-pretty (optional) activates pretty printing\n")
-	fmt.Println("--help (optional) shows usage\n")
+	fmt.Println("-pretty (optional) activates pretty printing")
+	fmt.Println("-indent (optional) spaces per indentation level when pretty printing, default is 4")
+	fmt.Println("-tabs (optional) indents using tabs when pretty printing")
+	fmt.Println("-newline (optional) line breaks written, crlf (default) or lf")
+	fmt.Println("-maxwidth (optional) line width at which long arrays and argument lists are wrapped when pretty printing, default is 100, 0 disables wrapping")
+	fmt.Println("-preservelines (optional) writes each SQF statement to the line number of the ASL statement, overrides -pretty")
+	fmt.Println("-minify (optional) writes the shortest SQF code, renaming private variables of functions, overrides -pretty and -preservelines")
+	fmt.Println("-keep-comments (optional) writes ASL comments to the SQF code, doc comments of functions (/** ... */) are always written")
+	fmt.Println("-force (optional) overwrites SQF files which were not generated by asl")
+	fmt.Println("-O0, -O1, -O2 (optional) optimization level, -O1 folds constant expressions and removes branches never executed, -O2 inlines small functions as well, default is -O0")
+	fmt.Println("-prune (optional) removes functions never used by any of the compiled files and reports them")
+	fmt.Println("-entry (optional) comma separated functions kept by -prune, like functions called by the mission config")
+	fmt.Println("-sourcemap (optional) writes a source map (.sqf.map) next to each output file")
+	fmt.Print("-format=json (optional) prints one JSON object per compiled file instead of text\n\n")
+	fmt.Print("--help (optional) shows usage\n\n")
 	fmt.Println("<input directory> directory to compile")
-	fmt.Println("<output directory> output directory, directory structure will be created corresponding to input directory")
+	fmt.Print("<output directory> output directory, directory structure will be created corresponding to input directory\n\n")
+	fmt.Print("Usage: asl fmt [-w|-l] <files or directories>\n\n")
+	fmt.Println("Prints ASL files in canonical layout.")
+	fmt.Println("-w (optional) writes the formatted code to the files instead")
+	fmt.Print("-l (optional) lists files which are not formatted\n\n")
+	fmt.Print("Usage: asl rpt [-dir=<directory>] <file.rpt>\n\n")
+	fmt.Println("Prints the RPT log with script errors in generated SQF files pointing to the ASL source code.")
+	fmt.Println("-dir (optional) directory the SQF files and source maps were compiled to, defaults to the working directory")
 }
 
 // Parses compiler flags.
@@ -57,7 +107,44 @@ func flags(flag string) bool {
 	} else if flag == "-r" {
 		recursive = true
 	} else if flag == "-pretty" {
-		pretty = true
+		options.Pretty = true
+	} else if strings.HasPrefix(flag, "-indent=") {
+		options.Indent = number(flag[len("-indent="):])
+	} else if flag == "-tabs" {
+		options.Tabs = true
+	} else if flag == "-newline=crlf" {
+		options.NewLine = sqf.CRLF
+	} else if flag == "-newline=lf" {
+		options.NewLine = sqf.LF
+	} else if strings.HasPrefix(flag, "-maxwidth=") {
+		options.MaxWidth = number(flag[len("-maxwidth="):])
+	} else if flag == "-preservelines" {
+		options.PreserveLines = true
+	} else if flag == "-minify" {
+		options.Minify = true
+	} else if flag == "-keep-comments" {
+		options.KeepComments = true
+	} else if flag == "-force" {
+		force = true
+	} else if flag == "-o0" {
+		optimize = optimizer.None
+	} else if flag == "-o1" {
+		optimize = optimizer.Fold
+	} else if flag == "-o2" {
+		optimize = optimizer.Inline
+	} else if flag == "-prune" {
+		prune = true
+	} else if strings.HasPrefix(flag, "-entry=") {
+		entries = append(entries, strings.Split(flag[len("-entry="):], ",")...)
+	} else if flag == "-sourcemap" {
+		sourceMap = true
+	} else if strings.HasPrefix(flag, "-format=") {
+		format = flag[len("-format="):]
+
+		if format != formatText && format != formatJSON {
+			addStartupError(diagnostic.InvalidFlag, "", "Unknown output format: "+format)
+			format = formatText
+		}
 	} else if flag == "--help" {
 		usage()
 		exit = true
@@ -66,12 +153,23 @@ func flags(flag string) bool {
 	return true
 }
 
+// Returns the number of a flag value.
+// If it is not a number or negative, an error will be reported.
+func number(value string) int {
+	n, err := strconv.Atoi(value)
+
+	if err != nil || n < 0 {
+		addStartupError(diagnostic.InvalidFlag, "", "Invalid number: "+value)
+	}
+
+	return n
+}
+
 // Loads types from types file.
-// If none is provided, an error will be printed.
+// If none is provided, an error will be reported.
 func loadTypes() {
 	if err := types.LoadTypes(typeinfo); err != nil {
-		fmt.Println("No 'types' file provided. Please add type information to this file from 'supportInfo' script command output.")
-		exit = true
+		addStartupError(diagnostic.IOError, typeinfo, "No 'types' file provided. Please add type information to this file from 'supportInfo' script command output.")
 	}
 }
 
@@ -80,7 +178,7 @@ func readAslFiles(path string) {
 	dir, err := ioutil.ReadDir(path)
 
 	if err != nil {
-		fmt.Println("Error reading in directory!")
+		addStartupError(diagnostic.IOError, path, "Error reading in directory: "+err.Error())
 		return
 	}
 
@@ -103,45 +201,390 @@ func readAslFiles(path string) {
 	}
 }
 
-// Recovers and prints thrown error.
-func recoverCompileError(file string) {
+// Adds a problem found before compiling, which is not related to source code.
+func addStartupError(code, file, msg string) {
+	startErrors = append(startErrors, diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		File:     file,
+		Message:  msg,
+		Code:     code})
+}
+
+// Prints the problems found before compiling, if any, and exits with status 1.
+func exitOnStartupErrors() {
+	if len(startErrors) > 0 {
+		fmt.Print(diagnostic.RenderStartup(startErrors, format == formatJSON, isTerminal()))
+		os.Exit(1)
+	}
+}
+
+// Prints the result of compiling a file.
+// As text, problems are printed together with the source code they refer to,
+// colored when printing to a terminal.
+func printResult(result diagnostic.Result) {
+	if format == formatJSON {
+		out, _ := json.Marshal(result)
+		fmt.Println(string(out))
+		return
+	}
+
+	fmt.Println(result.Input + " -> " + result.Output)
+
+	if len(result.Diagnostics) == 0 {
+		return
+	}
+
+	source, _ := ioutil.ReadFile(result.Input)
+
+	for _, d := range result.Diagnostics {
+		fmt.Print(diagnostic.Render(d, source, isTerminal()))
+	}
+}
+
+// Returns true if stdout is a terminal.
+func isTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Adds an error not related to source code to the result.
+func addError(result *diagnostic.Result, code, msg string) {
+	result.Diagnostics = append(result.Diagnostics, diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		File:     result.Input,
+		Message:  msg,
+		Code:     code})
+}
+
+// Recovers from thrown error, which is added to the result.
+func recoverCompileError(result *diagnostic.Result) {
 	if r := recover(); r != nil {
-		fmt.Println("Compile error in file "+file+":", r)
+		addError(result, diagnostic.InternalError, fmt.Sprint(r))
+	}
+}
+
+// Returns the result of compiling a single ASL file to the output path.
+func newResult(path string, file ASLFile) diagnostic.Result {
+	out := filepath.FromSlash(path + PathSeparator + file.out + PathSeparator + file.newname + sqfextension)
+	return diagnostic.Result{Input: file.in, Output: out, Status: diagnostic.StatusOk, Diagnostics: make([]diagnostic.Diagnostic, 0)}
+}
+
+// Writes the SQF file of a parsed ASL file and prints the result.
+// Files which could not be parsed are not written.
+func writeFile(result *diagnostic.Result, tree *ast.File) {
+	if tree != nil {
+		emitAndWrite(result, tree)
 	}
+
+	if diagnostic.HasErrors(result.Diagnostics) {
+		result.Status = diagnostic.StatusError
+		failed = true
+	}
+
+	printResult(*result)
 }
 
-// Compiles a single ASL file.
-func compileFile(path string, file ASLFile) {
-	defer recoverCompileError(file.in)
+// Parses the input file of result.
+// Returns nil if the file cannot be compiled.
+func parseFile(result *diagnostic.Result) *ast.File {
+	defer recoverCompileError(result)
 
 	// read file
-	out := filepath.FromSlash(path + PathSeparator + file.out + PathSeparator + file.newname + sqfextension)
-	fmt.Println(file.in + " -> " + out)
-	code, err := ioutil.ReadFile(file.in)
+	in, err := os.Open(result.Input)
 
 	if err != nil {
-		fmt.Println("Error reading file: " + file.in)
-		return
+		addError(result, diagnostic.IOError, "Error reading file: "+err.Error())
+		return nil
 	}
 
+	defer in.Close()
+
 	// compile
-	token := tokenizer.Tokenize(code, false)
+	lexer := tokenizer.NewLexer(in, result.Input)
+	lexer.EnableTrivia() // comments are written to the output
 	compiler := parser.Compiler{}
-	sqf := compiler.Parse(token, pretty)
+	tree, diagnostics := compiler.ParseAST(lexer)
+	result.Diagnostics = append(result.Diagnostics, diagnostics...)
+
+	if lexer.Err() != nil {
+		addError(result, diagnostic.IOError, "Error reading file: "+lexer.Err().Error())
+		return nil
+	}
+
+	if diagnostic.HasErrors(diagnostics) {
+		return nil
+	}
+
+	return tree
+}
+
+// Optimizes the parsed file of result.
+// Returns nil if the file cannot be compiled.
+func optimizeFile(result *diagnostic.Result, tree *ast.File) *ast.File {
+	defer recoverCompileError(result)
+
+	if tree == nil {
+		return nil
+	}
+
+	result.Diagnostics = append(result.Diagnostics, optimizer.Optimize(tree, optimize)...)
+	diagnostic.Sort(result.Diagnostics)
+	return tree
+}
+
+// Warns about calls of functions not declared by any of the parsed files, which are reported to the results of the calling files.
+// Nothing is checked if any file cannot be compiled, since the functions declared by it are unknown.
+func checkFunctionNames(results []diagnostic.Result, trees []*ast.File) {
+	for _, tree := range trees {
+		if tree == nil {
+			return
+		}
+	}
+
+	for _, d := range parser.CheckFunctionNames(trees) {
+		for i := range results {
+			if d.File == results[i].Input {
+				results[i].Diagnostics = append(results[i].Diagnostics, d)
+				diagnostic.Sort(results[i].Diagnostics)
+			}
+		}
+	}
+}
+
+// Removes the functions never used by the parsed files, which are reported to the results of the files declaring them.
+// Nothing is removed if any file cannot be compiled, since the functions used by it are unknown.
+func pruneFunctions(results []diagnostic.Result, trees []*ast.File) {
+	for _, tree := range trees {
+		if tree == nil {
+			return
+		}
+	}
+
+	for _, removed := range optimizer.Prune(trees, entries) {
+		for i, tree := range trees {
+			if tree != removed.File {
+				continue
+			}
+
+			results[i].Diagnostics = append(results[i].Diagnostics, diagnostic.Diagnostic{
+				Severity: diagnostic.Info,
+				File:     results[i].Input,
+				Range:    removed.Func.Name.Token.Range(),
+				Message:  "Removed function '" + removed.Func.Name.Name + "', " + removed.Reason,
+				Code:     diagnostic.RemovedFunction})
+			diagnostic.Sort(results[i].Diagnostics)
+		}
+	}
+}
+
+// Writes the SQF code of the abstract syntax tree to the output file of result.
+func emitAndWrite(result *diagnostic.Result, tree *ast.File) {
+	defer recoverCompileError(result)
+
+	// files generated before the header was introduced contain the same code without header
+	unstamped := func() string {
+		code, _ := sqf.EmitOptions(tree, options)
+		return code
+	}
+
+	if !force && !isGenerated(result.Output, unstamped) {
+		addError(result, diagnostic.NotGenerated, "Output file "+result.Output+" was not generated by asl and is not overwritten, "+
+			"use -force to overwrite it once if it was generated by asl before version 1.3.0")
+		return
+	}
+
+	fileOptions := options
+	fileOptions.Header = "Generated by asl " + version + " from " + filepath.ToSlash(filepath.Clean(result.Input)) + ", do not edit"
+	code, mappings := sqf.EmitOptions(tree, fileOptions)
+	os.MkdirAll(filepath.Dir(result.Output), 0777)
+	err := ioutil.WriteFile(result.Output, []byte(code), 0666)
+
+	if err != nil {
+		addError(result, diagnostic.IOError, "Error writing file: "+err.Error())
+		return
+	}
+
+	if sourceMap {
+		writeSourceMap(result, mappings)
+	}
+}
+
+// Returns true if the SQF file does not exist, starts with the header of generated files
+// or contains the unstamped code, which is only emitted if the file has no header.
+// Files which cannot be read are reported when writing them.
+func isGenerated(file string, unstamped func() string) bool {
+	content, err := ioutil.ReadFile(file)
 
-	os.MkdirAll(filepath.FromSlash(path+PathSeparator+file.out), 0777)
-	err = ioutil.WriteFile(out, []byte(sqf), 0666)
+	if err != nil {
+		return true
+	}
+
+	content = bytes.TrimPrefix(content, bom)
+
+	return bytes.HasPrefix(content, []byte(generatedStamp)) || string(content) == unstamped()
+}
+
+// Writes the source map for the output file of result next to it.
+func writeSourceMap(result *diagnostic.Result, mappings []sourcemap.Mapping) {
+	source := result.Input
+	dir, errDir := filepath.Abs(filepath.Dir(result.Output))
+	in, errIn := filepath.Abs(result.Input)
+
+	if errDir == nil && errIn == nil {
+		if rel, err := filepath.Rel(dir, in); err == nil {
+			source = rel
+		}
+	}
+
+	m := sourcemap.SourceMap{File: filepath.Base(result.Output), Source: filepath.ToSlash(source), Mappings: mappings}
+	data, err := m.Encode()
+
+	if err == nil {
+		err = ioutil.WriteFile(result.Output+mapextension, data, 0666)
+	}
 
 	if err != nil {
-		fmt.Println("Error writing file: " + file.out)
-		fmt.Println(err)
+		addError(result, diagnostic.IOError, "Error writing source map: "+err.Error())
 	}
 }
 
 // Compiles ASL files.
 func compile(path string) {
-	for i := 0; i < len(aslFiles); i++ {
-		compileFile(path, aslFiles[i])
+	results := make([]diagnostic.Result, len(aslFiles))
+	trees := make([]*ast.File, len(aslFiles))
+
+	for i, file := range aslFiles {
+		results[i] = newResult(path, file)
+		trees[i] = parseFile(&results[i])
+	}
+
+	// functions can be declared and used by any file, so all files are parsed before checking and writing them
+	checkFunctionNames(results, trees)
+
+	for i := range results {
+		trees[i] = optimizeFile(&results[i], trees[i])
+	}
+
+	if prune {
+		pruneFunctions(results, trees)
+	}
+
+	for i := range results {
+		writeFile(&results[i], trees[i])
+	}
+}
+
+// Prints the RPT log with script errors mapped back to ASL files.
+func runRpt(args []string) {
+	resolver := rpt.Resolver{Dir: "."}
+	file := ""
+
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-dir=") {
+			resolver.Dir = arg[len("-dir="):]
+		} else {
+			file = arg
+		}
+	}
+
+	if file == "" {
+		usage()
+		return
+	}
+
+	log, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		fmt.Println("Error reading file: " + err.Error())
+		os.Exit(1)
+	}
+
+	out, _ := rpt.Rewrite(string(log), &resolver)
+	fmt.Print(out)
+}
+
+// Formats ASL files and all ASL files within directories.
+// The formatted code is printed, unless files are written (-w) or listed if not formatted (-l).
+func runFmt(args []string) {
+	write, list := false, false
+	paths := make([]string, 0)
+
+	for _, arg := range args {
+		if arg == "-w" {
+			write = true
+		} else if arg == "-l" {
+			list = true
+		} else {
+			paths = append(paths, arg)
+		}
+	}
+
+	if len(paths) == 0 {
+		usage()
+		return
+	}
+
+	loadTypes()
+	exitOnStartupErrors()
+
+	for _, path := range paths {
+		err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && (file == path || strings.ToLower(filepath.Ext(file)) == extension) {
+				formatFile(file, info.Mode(), write, list)
+			}
+
+			return err
+		})
+
+		if err != nil {
+			fmt.Println("Error reading file: " + err.Error())
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// Formats a single ASL file. Files containing errors are left unchanged.
+func formatFile(file string, mode os.FileMode, write, list bool) {
+	code, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		fmt.Println("Error reading file: " + err.Error())
+		failed = true
+		return
+	}
+
+	out, diagnostics := formatter.Format(code, file)
+
+	if diagnostic.HasErrors(diagnostics) {
+		for _, d := range diagnostics {
+			if d.Severity == diagnostic.Error {
+				fmt.Print(diagnostic.Render(d, code, isTerminal()))
+			}
+		}
+
+		failed = true
+		return
+	}
+
+	changed := !bytes.Equal(code, out)
+
+	if list && changed {
+		fmt.Println(file)
+	}
+
+	if write && changed {
+		if err := ioutil.WriteFile(file, out, mode); err != nil {
+			fmt.Println("Error writing file: " + err.Error())
+			failed = true
+		}
+	}
+
+	if !write && !list {
+		os.Stdout.Write(out)
 	}
 }
 
@@ -154,20 +597,27 @@ func main() {
 		return
 	}
 
+	if args[1] == "rpt" {
+		runRpt(args[2:])
+		return
+	} else if args[1] == "fmt" {
+		runFmt(args[2:])
+		return
+	}
+
 	var i int
 	for i = 1; i < len(args) && flags(args[i]); i++ {
 	}
 
+	exitOnStartupErrors()
+
 	if exit {
 		return
 	}
 
 	// load type information
 	loadTypes()
-
-	if exit {
-		return
-	}
+	exitOnStartupErrors()
 
 	// in/out parameter
 	out := ""
@@ -184,5 +634,10 @@ func main() {
 	}
 
 	readAslFiles(inDir)
+	exitOnStartupErrors()
 	compile(out)
+
+	if failed {
+		os.Exit(1)
+	}
 }
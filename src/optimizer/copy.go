@@ -0,0 +1,163 @@
+package optimizer
+
+import (
+	"ast"
+	"strings"
+	"tokenizer"
+)
+
+// Copies statements and expressions of inlined functions.
+// Variables are renamed and parameters replaced by arguments, which are copied as well.
+// If move is true, the tokens are moved to the position of the call.
+type copier struct {
+	at    tokenizer.Token
+	move  bool
+	names map[string]string   // renamed variables by lower case name
+	args  map[string]ast.Expr // arguments replacing parameters by lower case name
+}
+
+// Returns the token, moved to the call if required.
+func (c *copier) token(token tokenizer.Token) tokenizer.Token {
+	if !c.move {
+		return token
+	}
+
+	moved := c.at
+	moved.Token = token.Token
+	moved.Kind = token.Kind
+	moved.Leading, moved.Trailing = nil, nil
+
+	return moved
+}
+
+// Returns a copy of a variable, which is renamed if required.
+func (c *copier) variable(ident *ast.Ident) *ast.Ident {
+	name := ident.Name
+
+	if renamed, ok := c.names[strings.ToLower(name)]; ok {
+		name = renamed
+	}
+
+	return &ast.Ident{Token: c.token(ident.Token), Name: name}
+}
+
+// Returns a copy of a function name, which is never renamed.
+func (c *copier) function(ident *ast.Ident) *ast.Ident {
+	return &ast.Ident{Token: c.token(ident.Token), Name: ident.Name}
+}
+
+func (c *copier) stmts(stmts []ast.Stmt) []ast.Stmt {
+	copied := make([]ast.Stmt, 0, len(stmts))
+
+	for _, stmt := range stmts {
+		copied = append(copied, c.stmt(stmt))
+	}
+
+	return copied
+}
+
+func (c *copier) block(block *ast.Block) *ast.Block {
+	if block == nil {
+		return nil
+	}
+
+	copied := &ast.Block{Token: c.token(block.Token), Stmts: c.stmts(block.Stmts), End: block.End}
+
+	// blocks without braces have no end
+	if block.End.Token != "" {
+		copied.End = c.token(block.End)
+	}
+
+	return copied
+}
+
+func (c *copier) stmt(stmt ast.Stmt) ast.Stmt {
+	switch n := stmt.(type) {
+	case *ast.Block:
+		return c.block(n)
+	case *ast.Preprocessor:
+		return &ast.Preprocessor{Token: c.token(n.Token), Text: n.Text}
+	case *ast.Var:
+		return &ast.Var{Token: c.token(n.Token), Name: c.variable(n.Name), Value: c.expr(n.Value)}
+	case *ast.Assign:
+		return &ast.Assign{Name: c.variable(n.Name), Value: c.expr(n.Value)}
+	case *ast.If:
+		return &ast.If{Token: c.token(n.Token), Cond: c.expr(n.Cond), Then: c.block(n.Then), Else: c.block(n.Else)}
+	case *ast.While:
+		return &ast.While{Token: c.token(n.Token), Cond: c.expr(n.Cond), Body: c.block(n.Body)}
+	case *ast.For:
+		return &ast.For{Token: c.token(n.Token), Var: n.Var, Init: c.expr(n.Init), Cond: c.expr(n.Cond), Post: c.expr(n.Post), Body: c.block(n.Body)}
+	case *ast.Foreach:
+		return &ast.Foreach{Token: c.token(n.Token), Elem: c.variable(n.Elem), Expr: c.expr(n.Expr), Body: c.block(n.Body)}
+	case *ast.Switch:
+		copied := &ast.Switch{Token: c.token(n.Token), Expr: c.expr(n.Expr), Cases: make([]*ast.Case, 0, len(n.Cases))}
+
+		for _, cs := range n.Cases {
+			copied.Cases = append(copied.Cases, &ast.Case{Token: c.token(cs.Token), Expr: c.expr(cs.Expr), Body: c.block(cs.Body)})
+		}
+
+		return copied
+	case *ast.Return:
+		return &ast.Return{Token: c.token(n.Token), Value: c.expr(n.Value)}
+	case *ast.Try:
+		return &ast.Try{Token: c.token(n.Token), Body: c.block(n.Body), Catch: c.block(n.Catch)}
+	case *ast.ExitWith:
+		return &ast.ExitWith{Token: c.token(n.Token), Body: c.block(n.Body)}
+	case *ast.WaitUntil:
+		return &ast.WaitUntil{Token: c.token(n.Token), Exprs: c.exprs(n.Exprs)}
+	case *ast.ExprStmt:
+		return &ast.ExprStmt{X: c.expr(n.X)}
+	}
+
+	// functions are never inlined
+	return stmt
+}
+
+func (c *copier) exprs(exprs []ast.Expr) []ast.Expr {
+	if exprs == nil {
+		return nil
+	}
+
+	copied := make([]ast.Expr, 0, len(exprs))
+
+	for _, expr := range exprs {
+		copied = append(copied, c.expr(expr))
+	}
+
+	return copied
+}
+
+// Returns a copy of the expression, nil for nil.
+func (c *copier) expr(expr ast.Expr) ast.Expr {
+	switch n := expr.(type) {
+	case *ast.Ident:
+		// arguments are copied as written by the caller
+		if arg, ok := c.args[strings.ToLower(n.Name)]; ok {
+			return (&copier{}).expr(arg)
+		}
+
+		return c.variable(n)
+	case *ast.Literal:
+		return &ast.Literal{Token: c.token(n.Token), Kind: n.Kind, Value: n.Value}
+	case *ast.Array:
+		return &ast.Array{Token: c.token(n.Token), Elems: c.exprs(n.Elems)}
+	case *ast.Paren:
+		return &ast.Paren{Token: c.token(n.Token), X: c.expr(n.X)}
+	case *ast.Unary:
+		return &ast.Unary{Token: c.token(n.Token), Op: n.Op, X: c.expr(n.X)}
+	case *ast.Binary:
+		return &ast.Binary{X: c.expr(n.X), Op: n.Op, OpToken: c.token(n.OpToken), Y: c.expr(n.Y)}
+	case *ast.Index:
+		return &ast.Index{X: c.expr(n.X), Index: c.expr(n.Index)}
+	case *ast.Call:
+		return &ast.Call{Name: c.function(n.Name), Args: c.exprs(n.Args)}
+	case *ast.BuiltinCall:
+		return &ast.BuiltinCall{Name: c.function(n.Name), Type: n.Type, Left: c.exprs(n.Left), Right: c.exprs(n.Right), Method: n.Method}
+	case *ast.Code:
+		return &ast.Code{Token: c.token(n.Token), Source: c.token(n.Source), Body: c.block(n.Body)}
+	case *ast.Interpolation:
+		return &ast.Interpolation{Token: c.token(n.Token), Format: n.Format, Args: c.exprs(n.Args)}
+	}
+
+	return nil
+}
@@ -0,0 +1,332 @@
+package optimizer
+
+import (
+	"ast"
+	"math"
+	"strconv"
+	"strings"
+	"tokenizer"
+)
+
+// Folds the constant expressions within the statements.
+// Statements which are never executed are removed.
+func foldStmts(stmts []ast.Stmt) []ast.Stmt {
+	folded := make([]ast.Stmt, 0, len(stmts))
+
+	for _, stmt := range stmts {
+		folded = append(folded, foldStmt(stmt)...)
+	}
+
+	return folded
+}
+
+func foldBlock(block *ast.Block) {
+	if block != nil {
+		block.Stmts = foldStmts(block.Stmts)
+	}
+}
+
+// Folds the constant expressions within the statement and returns the statements replacing it.
+// Ifs with constant conditions are replaced by the branch executed, whiles never executed are removed.
+func foldStmt(stmt ast.Stmt) []ast.Stmt {
+	switch n := stmt.(type) {
+	case *ast.Block:
+		foldBlock(n)
+	case *ast.Var:
+		if n.Value != nil {
+			n.Value = foldExpr(n.Value)
+		}
+	case *ast.Assign:
+		n.Value = foldExpr(n.Value)
+	case *ast.If:
+		n.Cond = foldExpr(n.Cond)
+		foldBlock(n.Then)
+		foldBlock(n.Else)
+
+		if value, ok := boolValue(n.Cond); ok {
+			branch := n.Then
+
+			if !value {
+				branch = n.Else
+			}
+
+			if branch == nil {
+				return nil
+			} else if inlineable(branch) {
+				return branch.Stmts
+			}
+		}
+	case *ast.While:
+		n.Cond = foldExpr(n.Cond)
+		foldBlock(n.Body)
+
+		if value, ok := boolValue(n.Cond); ok && !value {
+			return nil
+		}
+	case *ast.Switch:
+		n.Expr = foldExpr(n.Expr)
+
+		for _, c := range n.Cases {
+			if c.Expr != nil {
+				c.Expr = foldExpr(c.Expr)
+			}
+
+			foldBlock(c.Body)
+		}
+	case *ast.For:
+		n.Init = foldExpr(n.Init)
+		n.Cond = foldExpr(n.Cond)
+		n.Post = foldExpr(n.Post)
+		foldBlock(n.Body)
+	case *ast.Foreach:
+		n.Expr = foldExpr(n.Expr)
+		foldBlock(n.Body)
+	case *ast.Func:
+		for _, param := range n.Params {
+			if param.Default != nil {
+				param.Default = foldExpr(param.Default)
+			}
+		}
+
+		foldBlock(n.Body)
+	case *ast.Return:
+		n.Value = foldExpr(n.Value)
+	case *ast.Try:
+		foldBlock(n.Body)
+		foldBlock(n.Catch)
+	case *ast.ExitWith:
+		foldBlock(n.Body)
+	case *ast.WaitUntil:
+		foldExprs(n.Exprs)
+	case *ast.ExprStmt:
+		n.X = foldExpr(n.X)
+	}
+
+	return []ast.Stmt{stmt}
+}
+
+// Returns true if the statements of the block can replace the if statement containing it.
+// Blocks are scopes in SQF, so blocks declaring variables, leaving the scope or returning are kept.
+func inlineable(block *ast.Block) bool {
+	for _, stmt := range block.Stmts {
+		switch n := stmt.(type) {
+		case *ast.Var, *ast.ExitWith, *ast.Return:
+			return false
+		case *ast.Assign:
+			if strings.HasPrefix(n.Name.Name, "_") {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func foldExprs(exprs []ast.Expr) {
+	for i := range exprs {
+		exprs[i] = foldExpr(exprs[i])
+	}
+}
+
+// Returns the expression with constant parts folded.
+func foldExpr(expr ast.Expr) ast.Expr {
+	switch n := expr.(type) {
+	case *ast.Array:
+		foldExprs(n.Elems)
+	case *ast.Paren:
+		n.X = foldExpr(n.X)
+
+		// constants do not need parentheses
+		if _, ok := n.X.(*ast.Literal); ok {
+			return n.X
+		}
+	case *ast.Unary:
+		n.X = foldExpr(n.X)
+		return foldUnary(n)
+	case *ast.Binary:
+		n.X = foldExpr(n.X)
+		n.Y = foldExpr(n.Y)
+
+		if folded := foldBinary(n); folded != nil {
+			return folded
+		}
+	case *ast.Index:
+		n.X = foldExpr(n.X)
+		n.Index = foldExpr(n.Index)
+	case *ast.Call:
+		foldExprs(n.Args)
+	case *ast.BuiltinCall:
+		foldExprs(n.Left)
+		foldExprs(n.Right)
+	case *ast.Code:
+		foldBlock(n.Body)
+	case *ast.Interpolation:
+		foldExprs(n.Args)
+	}
+
+	return expr
+}
+
+// Folds negations of constants and removes double negations.
+func foldUnary(n *ast.Unary) ast.Expr {
+	inner, double := unparen(n.X).(*ast.Unary)
+
+	if n.Op == "-" {
+		if double && inner.Op == "-" {
+			return inner.X
+		}
+	} else if value, ok := boolValue(n.X); ok {
+		return boolLiteral(!value, n.Token)
+	} else if double && (inner.Op == "!" || inner.Op == "not") {
+		return inner.X
+	}
+
+	return n
+}
+
+// Returns the result of a binary operation on constants, or nil if it cannot be folded.
+// Numbers are calculated with the precision of SQF. Strings are concatenated,
+// but not compared, since SQF compares strings case insensitive.
+func foldBinary(n *ast.Binary) ast.Expr {
+	token := n.Pos()
+
+	if a, ok := numberValue(n.X); ok {
+		if b, ok := numberValue(n.Y); ok {
+			return foldNumbers(n.Op, a, b, token)
+		}
+	}
+
+	if a, ok := boolValue(n.X); ok {
+		if b, ok := boolValue(n.Y); ok {
+			switch n.Op {
+			case "&&", "and":
+				return boolLiteral(a && b, token)
+			case "||", "or":
+				return boolLiteral(a || b, token)
+			}
+		}
+	}
+
+	if a, ok := stringValue(n.X); ok && n.Op == "+" {
+		if b, ok := stringValue(n.Y); ok {
+			return &ast.Literal{Token: token, Kind: tokenizer.String, Value: tokenizer.Quote(a + b)}
+		}
+	}
+
+	return nil
+}
+
+func foldNumbers(op string, a, b float32, token tokenizer.Token) ast.Expr {
+	var result float32
+
+	switch op {
+	case "+":
+		result = a + b
+	case "-":
+		result = a - b
+	case "*":
+		result = a * b
+	case "/":
+		if b == 0 {
+			return nil
+		}
+
+		result = a / b
+	case "<":
+		return boolLiteral(a < b, token)
+	case ">":
+		return boolLiteral(a > b, token)
+	case "<=":
+		return boolLiteral(a <= b, token)
+	case ">=":
+		return boolLiteral(a >= b, token)
+	case "==":
+		return boolLiteral(a == b, token)
+	case "!=":
+		return boolLiteral(a != b, token)
+	default:
+		return nil
+	}
+
+	if math.IsInf(float64(result), 0) || math.IsNaN(float64(result)) {
+		return nil
+	}
+
+	return numberLiteral(result, token)
+}
+
+// Returns the value of a constant number, which can be negated.
+func numberValue(expr ast.Expr) (float32, bool) {
+	switch n := expr.(type) {
+	case *ast.Paren:
+		return numberValue(n.X)
+	case *ast.Unary:
+		if n.Op == "-" {
+			value, ok := numberValue(n.X)
+			return -value, ok
+		}
+	case *ast.Literal:
+		if n.Kind != tokenizer.Number {
+			break
+		}
+
+		value := strings.ToLower(n.Value)
+
+		if strings.HasPrefix(value, "$") || strings.HasPrefix(value, "0x") {
+			hex, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(value, "$"), "0x"), 16, 64)
+			return float32(hex), err == nil
+		}
+
+		number, err := strconv.ParseFloat(value, 32)
+		return float32(number), err == nil
+	}
+
+	return 0, false
+}
+
+// Returns the value of a constant boolean.
+func boolValue(expr ast.Expr) (bool, bool) {
+	if literal, ok := unparen(expr).(*ast.Literal); ok && literal.Kind == tokenizer.Keyword {
+		return literal.Value == "true", true
+	}
+
+	return false, false
+}
+
+// Returns the value of a constant string.
+func stringValue(expr ast.Expr) (string, bool) {
+	if literal, ok := unparen(expr).(*ast.Literal); ok && literal.Kind == tokenizer.String {
+		return tokenizer.Unquote(literal.Value), true
+	}
+
+	return "", false
+}
+
+// Returns a number literal, negative numbers are negated literals.
+func numberLiteral(value float32, token tokenizer.Token) ast.Expr {
+	if value < 0 {
+		return &ast.Unary{Token: token, Op: "-", X: numberLiteral(-value, token)}
+	}
+
+	// no negative zero
+	str := strconv.FormatFloat(float64(value+0), 'f', -1, 32)
+
+	if exponent := strings.Replace(strconv.FormatFloat(float64(value), 'g', -1, 32), "e+", "e", 1); len(exponent) < len(str) {
+		str = exponent
+	}
+
+	return &ast.Literal{Token: token, Kind: tokenizer.Number, Value: str}
+}
+
+func boolLiteral(value bool, token tokenizer.Token) ast.Expr {
+	return &ast.Literal{Token: token, Kind: tokenizer.Keyword, Value: strconv.FormatBool(value)}
+}
+
+// Returns the expression within parentheses.
+func unparen(expr ast.Expr) ast.Expr {
+	if paren, ok := expr.(*ast.Paren); ok {
+		return unparen(paren.X)
+	}
+
+	return expr
+}
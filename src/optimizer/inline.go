@@ -0,0 +1,568 @@
+package optimizer
+
+import (
+	"ast"
+	"diagnostic"
+	"regexp"
+	"strconv"
+	"strings"
+	"tokenizer"
+)
+
+// maximum number of nodes of a function body, for the function to be inlined without inline func
+const inlineSize = 12
+
+// private variables named within strings, like isNil "_var"
+var variableInString = regexp.MustCompile(`_[a-zA-Z0-9_]+`)
+
+// Function which is copied into its calls.
+type inlineFunc struct {
+	f        *ast.Func
+	stmts    []ast.Stmt      // copy of the statements in front of the final return statement
+	result   ast.Expr        // copy of the value of the final return statement, nil if there is none
+	locals   []string        // variables declared within the body in lower case
+	assigned map[string]bool // variables declared or assigned within the body in lower case
+	pure     bool            // the body calls no functions declared in ASL
+	scopes   bool            // the body contains foreach loops or inline code, which set _x
+}
+
+type inliner struct {
+	funcs map[string]*inlineFunc // by lower case name
+	used  map[string]bool        // identifiers used within the file in lower case
+	n     int                    // number of inlined calls, used to name their variables
+}
+
+// Copies the bodies of functions declared using inline func into their calls within the file.
+// Small functions are inlined as well, if auto is true. Parameters and variables of inlined functions are
+// renamed, so that they do not change variables of the caller. Functions which cannot be inlined, since they
+// return early or are recursive, stay calls. This is reported for functions declared using inline func.
+func inlineFunctions(file *ast.File, auto bool) []diagnostic.Diagnostic {
+	diagnostics := make([]diagnostic.Diagnostic, 0)
+	in := inliner{funcs: make(map[string]*inlineFunc), used: identifiers(file)}
+	declared := make(map[string]int)
+
+	for _, stmt := range file.Stmts {
+		if f, ok := stmt.(*ast.Func); ok {
+			declared[strings.ToLower(f.Name.Name)]++
+		}
+	}
+
+	for _, stmt := range file.Stmts {
+		f, ok := stmt.(*ast.Func)
+
+		if !ok || !f.Inline && (!auto || size(f.Body) > inlineSize) {
+			continue
+		}
+
+		reason := notInlineable(f)
+
+		if reason == "" && declared[strings.ToLower(f.Name.Name)] > 1 {
+			reason = "it is declared multiple times"
+		}
+
+		if reason == "" {
+			in.funcs[strings.ToLower(f.Name.Name)] = newInlineFunc(f)
+		} else if f.Inline {
+			diagnostics = append(diagnostics, notInlined(f, reason))
+		}
+	}
+
+	// recursive functions would be copied endlessly
+	recursive := make([]string, 0)
+
+	for _, stmt := range file.Stmts {
+		if f, ok := stmt.(*ast.Func); ok && in.funcs[strings.ToLower(f.Name.Name)] != nil {
+			name := strings.ToLower(f.Name.Name)
+
+			if in.calls(name, name, make(map[string]bool)) {
+				recursive = append(recursive, name)
+
+				if f.Inline {
+					diagnostics = append(diagnostics, notInlined(f, "it is recursive"))
+				}
+			}
+		}
+	}
+
+	for _, name := range recursive {
+		delete(in.funcs, name)
+	}
+
+	if len(in.funcs) > 0 {
+		file.Stmts = in.stmts(file.Stmts)
+	}
+
+	return diagnostics
+}
+
+func notInlined(f *ast.Func, reason string) diagnostic.Diagnostic {
+	return diagnostic.Diagnostic{
+		Severity: diagnostic.Warning,
+		File:     f.Name.Token.File,
+		Range:    f.Name.Token.Range(),
+		Message:  "Function '" + f.Name.Name + "' is not inlined, " + reason,
+		Code:     diagnostic.NotInlined}
+}
+
+// Returns why the function cannot be inlined, or an empty string if it can.
+func notInlineable(f *ast.Func) string {
+	stmts := f.Body.Stmts
+
+	for _, stmt := range stmts {
+		switch stmt.(type) {
+		case *ast.ExitWith:
+			return "it leaves its scope using exitwith"
+		case *ast.Preprocessor:
+			return "it contains preprocessor directives"
+		}
+	}
+
+	variables := make(map[string]bool)
+
+	for _, name := range append(parameters(f), declaredLocals(f.Body)...) {
+		variables[name] = true
+	}
+
+	reason := ""
+
+	ast.Inspect(f.Body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Return:
+			if n != stmts[len(stmts)-1] {
+				reason = "it returns early"
+			}
+		case *ast.Func:
+			reason = "it declares functions"
+		case *ast.Ident:
+			if strings.ToLower(n.Name) == "_this" {
+				reason = "it uses _this"
+			}
+		case *ast.Literal:
+			if n.Kind != tokenizer.String {
+				break
+			}
+
+			for _, name := range variableInString.FindAllString(n.Value, -1) {
+				if variables[strings.ToLower(name)] {
+					reason = "it names its variables within strings"
+				}
+			}
+		}
+
+		return reason == ""
+	})
+
+	return reason
+}
+
+// Returns the function to inline. The body is copied, since calls within the declared function are inlined
+// in place, while the copy must keep them, so that they are inlined into each call using fresh variables.
+func newInlineFunc(f *ast.Func) *inlineFunc {
+	fn := &inlineFunc{f: f, stmts: f.Body.Stmts, locals: declaredLocals(f.Body), assigned: make(map[string]bool), pure: true}
+
+	if len(fn.stmts) > 0 {
+		if ret, ok := fn.stmts[len(fn.stmts)-1].(*ast.Return); ok {
+			fn.stmts = fn.stmts[:len(fn.stmts)-1]
+			fn.result = (&copier{}).expr(ret.Value)
+		}
+	}
+
+	fn.stmts = (&copier{}).stmts(fn.stmts)
+
+	ast.Inspect(f.Body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Var:
+			fn.assigned[strings.ToLower(n.Name.Name)] = true
+		case *ast.Assign:
+			fn.assigned[strings.ToLower(n.Name.Name)] = true
+		case *ast.For:
+			if ident := forVariable(n); ident != nil {
+				fn.assigned[strings.ToLower(ident.Name)] = true
+			}
+		case *ast.Foreach:
+			fn.assigned[strings.ToLower(n.Elem.Name)] = true
+			fn.scopes = true
+		case *ast.Code:
+			fn.scopes = true
+		case *ast.Call:
+			fn.pure = false
+		}
+
+		return true
+	})
+
+	return fn
+}
+
+// Returns true if the function calls the target function directly or through other inlined functions.
+func (in *inliner) calls(name, target string, visited map[string]bool) bool {
+	called := false
+
+	ast.Inspect(in.funcs[name].f.Body, func(node ast.Node) bool {
+		call, ok := node.(*ast.Call)
+
+		if !ok || called {
+			return !called
+		}
+
+		callee := strings.ToLower(call.Name.Name)
+
+		if callee == target {
+			called = true
+		} else if in.funcs[callee] != nil && !visited[callee] {
+			visited[callee] = true
+			called = in.calls(callee, target, visited)
+		}
+
+		return !called
+	})
+
+	return called
+}
+
+func (in *inliner) stmts(stmts []ast.Stmt) []ast.Stmt {
+	inlined := make([]ast.Stmt, 0, len(stmts))
+
+	for _, stmt := range stmts {
+		inlined = append(inlined, in.stmt(stmt)...)
+	}
+
+	return inlined
+}
+
+func (in *inliner) block(block *ast.Block) {
+	if block != nil {
+		block.Stmts = in.stmts(block.Stmts)
+	}
+}
+
+// Returns the statements replacing the statement. Calls which are statements, assigned or returned are replaced
+// by the statements of the function, which are processed again, since they can call inlined functions too.
+func (in *inliner) stmt(stmt ast.Stmt) []ast.Stmt {
+	switch n := stmt.(type) {
+	case *ast.Block:
+		in.block(n)
+	case *ast.Var:
+		if stmts, result, ok := in.expandValue(n.Value); ok {
+			n.Value = result
+			return in.stmts(append(stmts, n))
+		}
+
+		if n.Value != nil {
+			n.Value = in.expr(n.Value)
+		}
+	case *ast.Assign:
+		if stmts, result, ok := in.expandValue(n.Value); ok {
+			n.Value = result
+			return in.stmts(append(stmts, n))
+		}
+
+		n.Value = in.expr(n.Value)
+	case *ast.Return:
+		if stmts, result, ok := in.expandValue(n.Value); ok {
+			n.Value = result
+			return in.stmts(append(stmts, n))
+		}
+
+		n.Value = in.expr(n.Value)
+	case *ast.ExprStmt:
+		if call, ok := n.X.(*ast.Call); ok {
+			if stmts, result, ok := in.expand(call, true, false); ok {
+				// the result is only kept for its side effects
+				if result != nil && hasCalls(result) {
+					stmts = append(stmts, &ast.ExprStmt{X: result})
+				}
+
+				return in.stmts(stmts)
+			}
+		}
+
+		n.X = in.expr(n.X)
+	case *ast.If:
+		n.Cond = in.expr(n.Cond)
+		in.block(n.Then)
+		in.block(n.Else)
+	case *ast.While:
+		n.Cond = in.expr(n.Cond)
+		in.block(n.Body)
+	case *ast.Switch:
+		n.Expr = in.expr(n.Expr)
+
+		for _, c := range n.Cases {
+			if c.Expr != nil {
+				c.Expr = in.expr(c.Expr)
+			}
+
+			in.block(c.Body)
+		}
+	case *ast.For:
+		n.Init = in.expr(n.Init)
+		n.Cond = in.expr(n.Cond)
+		n.Post = in.expr(n.Post)
+		in.block(n.Body)
+	case *ast.Foreach:
+		n.Expr = in.expr(n.Expr)
+		in.block(n.Body)
+	case *ast.Func:
+		in.block(n.Body)
+	case *ast.Try:
+		in.block(n.Body)
+		in.block(n.Catch)
+	case *ast.ExitWith:
+		in.block(n.Body)
+	case *ast.WaitUntil:
+		in.exprs(n.Exprs)
+	}
+
+	return []ast.Stmt{stmt}
+}
+
+// Expands a call whose value is used by a statement, like var x = f(y);
+func (in *inliner) expandValue(expr ast.Expr) ([]ast.Stmt, ast.Expr, bool) {
+	if call, ok := expr.(*ast.Call); ok {
+		return in.expand(call, true, true)
+	}
+
+	return nil, nil, false
+}
+
+func (in *inliner) exprs(exprs []ast.Expr) {
+	for i := range exprs {
+		exprs[i] = in.expr(exprs[i])
+	}
+}
+
+// Returns the expression with calls of functions, which only return a value, replaced by the value.
+func (in *inliner) expr(expr ast.Expr) ast.Expr {
+	switch n := expr.(type) {
+	case *ast.Array:
+		in.exprs(n.Elems)
+	case *ast.Paren:
+		n.X = in.expr(n.X)
+	case *ast.Unary:
+		n.X = in.expr(n.X)
+	case *ast.Binary:
+		n.X = in.expr(n.X)
+		n.Y = in.expr(n.Y)
+	case *ast.Index:
+		n.X = in.expr(n.X)
+		n.Index = in.expr(n.Index)
+	case *ast.Call:
+		in.exprs(n.Args)
+
+		if _, result, ok := in.expand(n, false, true); ok {
+			return &ast.Paren{Token: n.Name.Token, X: in.expr(result)}
+		}
+	case *ast.BuiltinCall:
+		in.exprs(n.Left)
+		in.exprs(n.Right)
+	case *ast.Code:
+		in.block(n.Body)
+	case *ast.Interpolation:
+		in.exprs(n.Args)
+	}
+
+	return expr
+}
+
+// Returns a copy of the statements in front of the final return statement and its value for a call.
+// Arguments which are constants or variables replace the parameters, others are assigned to renamed
+// parameters in order, which is only done if bind is true. Value requires the function to return a value.
+// Returns false if the call cannot be inlined.
+func (in *inliner) expand(call *ast.Call, bind, value bool) ([]ast.Stmt, ast.Expr, bool) {
+	fn := in.funcs[strings.ToLower(call.Name.Name)]
+
+	if fn == nil || len(call.Args) > len(fn.f.Params) || value && fn.result == nil || !bind && len(fn.stmts) > 0 {
+		return nil, nil, false
+	}
+
+	args := make([]ast.Expr, len(fn.f.Params))
+
+	for i, param := range fn.f.Params {
+		if i < len(call.Args) {
+			args[i] = call.Args[i]
+		} else if literal, ok := param.Default.(*ast.Literal); ok {
+			args[i] = (&copier{}).expr(literal)
+		} else {
+			return nil, nil, false
+		}
+
+		if !bind && !fn.substitutable(param.Name.Name, args[i]) {
+			return nil, nil, false
+		}
+	}
+
+	c := copier{at: call.Name.Token, move: true, names: make(map[string]string), args: make(map[string]ast.Expr)}
+	renamed := append([]string{}, fn.locals...)
+
+	for i, param := range fn.f.Params {
+		if name := strings.ToLower(param.Name.Name); fn.substitutable(name, args[i]) {
+			c.args[name] = args[i]
+		} else {
+			renamed = append(renamed, name)
+		}
+	}
+
+	if len(renamed) > 0 {
+		prefix := in.prefix(renamed)
+
+		for _, name := range renamed {
+			c.names[name] = prefix + strings.TrimPrefix(name, "_")
+		}
+	}
+
+	stmts := make([]ast.Stmt, 0)
+
+	for i, param := range fn.f.Params {
+		if name := strings.ToLower(param.Name.Name); c.args[name] == nil {
+			stmts = append(stmts, &ast.Var{Token: c.token(call.Name.Token), Name: c.variable(param.Name), Value: args[i]})
+		}
+	}
+
+	stmts = append(stmts, c.stmts(fn.stmts)...)
+	var result ast.Expr
+
+	if fn.result != nil {
+		result = c.expr(fn.result)
+	}
+
+	return stmts, result, true
+}
+
+// Returns the prefix of the variables of an inlined call, like _inline1_, which is not used by the file for any of the names.
+func (in *inliner) prefix(names []string) string {
+	for {
+		in.n++
+		prefix := "_inline" + strconv.Itoa(in.n) + "_"
+		used := false
+
+		for _, name := range names {
+			used = used || in.used[prefix+strings.TrimPrefix(name, "_")]
+		}
+
+		if !used {
+			for _, name := range names {
+				in.used[prefix+strings.TrimPrefix(name, "_")] = true
+			}
+
+			return prefix
+		}
+	}
+}
+
+// Returns true if the argument can replace the parameter within the copied body, instead of assigning it to a variable.
+// Constants can replace parameters which are not assigned, variables if they cannot be changed before they are read
+// and cannot be hidden by the _x of foreach loops and inline code.
+func (fn *inlineFunc) substitutable(param string, arg ast.Expr) bool {
+	if fn.assigned[strings.ToLower(param)] {
+		return false
+	}
+
+	switch n := arg.(type) {
+	case *ast.Literal:
+		return true
+	case *ast.Unary:
+		_, ok := n.X.(*ast.Literal)
+		return ok && n.Op == "-"
+	case *ast.Ident:
+		name := strings.ToLower(n.Name)
+		return fn.pure && !fn.assigned[name] && (!strings.HasPrefix(name, "_") || !fn.scopes)
+	}
+
+	return false
+}
+
+// Returns the parameters of the function in lower case.
+func parameters(f *ast.Func) []string {
+	names := make([]string, 0, len(f.Params))
+
+	for _, param := range f.Params {
+		names = append(names, strings.ToLower(param.Name.Name))
+	}
+
+	return names
+}
+
+// Returns the private variables declared within the block in lower case, in order of declaration.
+func declaredLocals(block *ast.Block) []string {
+	names := make([]string, 0)
+	declare := func(ident *ast.Ident) {
+		if name := strings.ToLower(ident.Name); strings.HasPrefix(name, "_") {
+			names = appendUnique(names, name)
+		}
+	}
+
+	ast.Inspect(block, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Var:
+			declare(n.Name)
+		case *ast.Foreach:
+			declare(n.Elem)
+		case *ast.For:
+			if ident := forVariable(n); ident != nil && n.Var {
+				declare(ident)
+			}
+		}
+
+		return true
+	})
+
+	return names
+}
+
+// Returns the variable initialized by a for loop, or nil.
+func forVariable(n *ast.For) *ast.Ident {
+	if init, ok := n.Init.(*ast.Binary); ok {
+		if ident, ok := init.X.(*ast.Ident); ok {
+			return ident
+		}
+	}
+
+	return nil
+}
+
+// Returns the identifiers used within the file in lower case.
+func identifiers(file *ast.File) map[string]bool {
+	used := make(map[string]bool)
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok {
+			used[strings.ToLower(ident.Name)] = true
+		}
+
+		return true
+	})
+
+	return used
+}
+
+// Returns the number of nodes.
+func size(node ast.Node) int {
+	n := 0
+
+	ast.Inspect(node, func(node ast.Node) bool {
+		if node != nil {
+			n++
+		}
+
+		return true
+	})
+
+	return n
+}
+
+// Returns true if the expression calls any function.
+func hasCalls(expr ast.Expr) bool {
+	calls := false
+
+	ast.Inspect(expr, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.Call, *ast.BuiltinCall:
+			calls = true
+		}
+
+		return !calls
+	})
+
+	return calls
+}
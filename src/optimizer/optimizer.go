@@ -0,0 +1,26 @@
+package optimizer
+
+import (
+	"ast"
+	"diagnostic"
+)
+
+// Optimization levels, selected by -O0, -O1 and -O2.
+const (
+	None   = 0 // code is compiled as written
+	Fold   = 1 // constant expressions are folded, branches never executed are removed
+	Inline = 2 // small functions are inlined like functions declared using inline func
+)
+
+// Optimizes the abstract syntax tree in place at given level.
+// Functions declared using inline func are inlined at all levels.
+// Returns warnings about functions which cannot be inlined.
+func Optimize(file *ast.File, level int) []diagnostic.Diagnostic {
+	diagnostics := inlineFunctions(file, level >= Inline)
+
+	if level >= Fold {
+		file.Stmts = foldStmts(file.Stmts)
+	}
+
+	return diagnostics
+}
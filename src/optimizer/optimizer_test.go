@@ -0,0 +1,116 @@
+package optimizer_test
+
+import (
+	"ast"
+	"diagnostic"
+	"optimizer"
+	"os"
+	"parser"
+	"sqf"
+	"strings"
+	"testing"
+	"tokenizer"
+	"types"
+)
+
+const (
+	types_file = "../../test/types"
+)
+
+func TestOptimizerFold(t *testing.T) {
+	got := getOptimized(t, "../../test/optimizer_fold.asl", optimizer.Fold)
+	want := "a = 9;b = -6;c = \"Hello World\";d = true;e = x;f = 255.3;g = x/0;[] call bar;if (true) then {_local = 1;};"
+	equal(t, got, want)
+}
+
+func TestOptimizerNone(t *testing.T) {
+	got := getOptimized(t, "../../test/optimizer_fold.asl", optimizer.None)
+	want := "a = 33/3-2;b = -(1+(2+3));"
+	equal(t, got[:len(want)], want)
+}
+
+func TestOptimizerInline(t *testing.T) {
+	tree := getTree(t, "../../test/optimizer_inline.asl")
+	diagnostics := optimizer.Optimize(tree, optimizer.None)
+	got := sqf.Emit(tree, false)
+	want := "a = _r*_r;_inline1_x = ([] call foo);b = _inline1_x*_inline1_x;_inline2_v = _r;_inline2_r = _inline2_v*2;c = _inline2_r+1;d = ([2] call early)+([3] call fact);e = ([1, 2] call add);"
+	equal(t, got[strings.Index(got, "_r = 3;")+len("_r = 3;"):], want)
+
+	if len(diagnostics) != 2 || diagnostics[0].Code != diagnostic.NotInlined || diagnostics[1].Code != diagnostic.NotInlined {
+		t.Fatalf("Functions returning early and recursive functions must be reported, got %v", diagnostics)
+	}
+
+	if diagnostics[0].Message != "Function 'early' is not inlined, it returns early" ||
+		diagnostics[1].Message != "Function 'fact' is not inlined, it is recursive" {
+		t.Errorf("Reasons must be reported, got %v", diagnostics)
+	}
+}
+
+func TestOptimizerInlineNested(t *testing.T) {
+	got := getOptimized(t, "../../test/optimizer_inline_nested.asl", optimizer.None)
+	want := "_inline2_b = _q+1;_inline3_a = _inline2_b+1;_inline3_t = _inline3_a*2;_inline2_r = _inline3_t;x = _inline2_r;" +
+		"_inline4_b = _q+2;_inline5_a = _inline4_b+1;_inline5_t = _inline5_a*2;_inline4_r = _inline5_t;y = _inline4_r;"
+	equal(t, got[strings.Index(got, "return _r;};")+len("return _r;};"):], want)
+}
+
+func TestOptimizerInlineAuto(t *testing.T) {
+	tree := getTree(t, "../../test/optimizer_inline.asl")
+	optimizer.Optimize(tree, optimizer.Inline)
+	got := sqf.Emit(tree, false)
+	want := "e = 3;"
+	equal(t, got[len(got)-len(want):], want)
+}
+
+func TestOptimizerPrune(t *testing.T) {
+	types.LoadTypes(types_file)
+
+	lib := getTree(t, "../../test/optimizer_prune_lib.asl")
+	main := getTree(t, "../../test/optimizer_prune_main.asl")
+	removed := optimizer.Prune([]*ast.File{lib, main}, []string{"Entry"})
+
+	if len(removed) != 3 {
+		t.Fatalf("Three functions must be removed, got %v", len(removed))
+	}
+
+	reasons := []string{"it is never used", "it is only used by removed functions 'unused'", "it is never used"}
+
+	for i, name := range []string{"unused", "helper", "recursive"} {
+		if removed[i].File != lib || removed[i].Func.Name.Name != name || removed[i].Reason != reasons[i] {
+			t.Errorf("Function %v must be removed because %v, got %v because %v", name, reasons[i], removed[i].Func.Name.Name, removed[i].Reason)
+		}
+	}
+
+	got := sqf.Emit(lib, false)
+	want := "add = {params [\"a\",\"b\"];return a+b;};spawned = {hint \"spawned\";};compiled = {hint \"compiled\";};entry = {hint \"entry\";};"
+	equal(t, got, want)
+}
+
+func getOptimized(t *testing.T, file string, level int) string {
+	tree := getTree(t, file)
+	optimizer.Optimize(tree, level)
+
+	return sqf.Emit(tree, false)
+}
+
+func getTree(t *testing.T, file string) *ast.File {
+	in, err := os.Open(file)
+
+	if err != nil {
+		t.Fatal("Could not read test file: " + file)
+	}
+
+	defer in.Close()
+	compiler := parser.Compiler{}
+	tree, _ := compiler.ParseAST(tokenizer.NewLexer(in, file))
+
+	return tree
+}
+
+func equal(t *testing.T, got, want string) {
+	if got != want {
+		t.Error("Results do not equal, got:")
+		t.Log(got)
+		t.Log("expected:")
+		t.Log(want)
+	}
+}
@@ -0,0 +1,177 @@
+package optimizer
+
+import (
+	"ast"
+	"regexp"
+	"strings"
+	"tokenizer"
+)
+
+// Function removed from a file by Prune.
+type Removed struct {
+	File   *ast.File
+	Func   *ast.Func
+	Reason string // why the function is not used, like "it is never used"
+}
+
+// names within strings, like compile "[] call foo" or missionNamespace getVariable "foo"
+var nameInString = regexp.MustCompile(`[a-zA-Z_][a-zA-Z0-9_]*`)
+
+// Removes the functions declared in the files, which are never used by the program made of all files.
+// The program starts at the statements outside of functions and the entry functions, like functions
+// called by the mission config. Functions are used by calls, by name, like in [] spawn foo, or
+// within strings and functions used by the program use the functions they call.
+// Returns the removed functions in order of the files.
+func Prune(files []*ast.File, entries []string) []Removed {
+	declared := make(map[string]bool)
+
+	for _, file := range files {
+		for _, stmt := range file.Stmts {
+			if f, ok := stmt.(*ast.Func); ok {
+				declared[strings.ToLower(f.Name.Name)] = true
+			}
+		}
+	}
+
+	// call graph by lower case function name, callers are kept as written for the report
+	calls := make(map[string][]string)
+	callers := make(map[string][]string)
+	used := make(map[string]bool)
+	queue := make([]string, 0)
+	use := func(name string) {
+		if declared[name] && !used[name] {
+			used[name] = true
+			queue = append(queue, name)
+		}
+	}
+
+	for _, entry := range entries {
+		use(strings.ToLower(entry))
+	}
+
+	for _, file := range files {
+		for _, stmt := range file.Stmts {
+			f, ok := stmt.(*ast.Func)
+
+			if !ok {
+				for _, name := range usedFunctions(stmt, declared) {
+					use(name)
+				}
+
+				continue
+			}
+
+			caller := strings.ToLower(f.Name.Name)
+
+			for _, name := range usedFunctions(f, declared) {
+				calls[caller] = append(calls[caller], name)
+
+				if name != caller {
+					callers[name] = appendUnique(callers[name], f.Name.Name)
+				}
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		for _, called := range calls[name] {
+			use(called)
+		}
+	}
+
+	removed := make([]Removed, 0)
+
+	for _, file := range files {
+		stmts := make([]ast.Stmt, 0, len(file.Stmts))
+
+		for _, stmt := range file.Stmts {
+			f, ok := stmt.(*ast.Func)
+
+			if !ok || used[strings.ToLower(f.Name.Name)] {
+				stmts = append(stmts, stmt)
+				continue
+			}
+
+			reason := "it is never used"
+
+			if names := callers[strings.ToLower(f.Name.Name)]; len(names) > 0 {
+				reason = "it is only used by removed functions '" + strings.Join(names, "', '") + "'"
+			}
+
+			removed = append(removed, Removed{File: file, Func: f, Reason: reason})
+			removeComments(file, f)
+		}
+
+		file.Stmts = stmts
+	}
+
+	return removed
+}
+
+// Returns the declared functions used within the node in lower case.
+// The names and parameters of function declarations do not use functions.
+func usedFunctions(node ast.Node, declared map[string]bool) []string {
+	names := make([]string, 0)
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Func:
+			for _, param := range n.Params {
+				if param.Default != nil {
+					names = append(names, usedFunctions(param.Default, declared)...)
+				}
+			}
+
+			names = append(names, usedFunctions(n.Body, declared)...)
+			return false
+		case *ast.Ident:
+			if name := strings.ToLower(n.Name); declared[name] {
+				names = append(names, name)
+			}
+		case *ast.Literal:
+			if n.Kind == tokenizer.String {
+				for _, name := range nameInString.FindAllString(strings.ToLower(n.Value), -1) {
+					if declared[name] {
+						names = append(names, name)
+					}
+				}
+			}
+		}
+
+		return true
+	})
+
+	return names
+}
+
+// Removes the comments written within the function and its doc comment.
+func removeComments(file *ast.File, f *ast.Func) {
+	start, end := f.Token.Start, f.Body.End.End
+
+	if f.Doc != nil {
+		start = f.Doc.Token.Start
+	}
+
+	comments := make([]*ast.Comment, 0, len(file.Comments))
+
+	for _, comment := range file.Comments {
+		if comment.Token.Start < start || comment.Token.Start >= end {
+			comments = append(comments, comment)
+		}
+	}
+
+	file.Comments = comments
+}
+
+func appendUnique(list []string, str string) []string {
+	for _, s := range list {
+		if s == str {
+			return list
+		}
+	}
+
+	return append(list, str)
+}
@@ -1,567 +1,883 @@
 package parser
 
 import (
-	"errors"
+	"ast"
+	"cst"
+	"diagnostic"
+	"sqf"
+	"strconv"
+	"strings"
 	"tokenizer"
 	"types"
 )
 
-const new_line = "\r\n"
-
 // Parses tokens, validates code to a specific degree
 // and writes SQF code into desired location.
-func (c *Compiler) Parse(token []tokenizer.Token, prettyPrinting bool) string {
-	if !c.initParser(token, prettyPrinting) {
-		return ""
+// All problems found are returned as diagnostics, the SQF code is incomplete if there are errors.
+func (c *Compiler) Parse(token []tokenizer.Token, prettyPrinting bool) (string, []diagnostic.Diagnostic) {
+	file, diagnostics := c.parseFile(&tokenList{tokens: token})
+	return sqf.Emit(file, prettyPrinting), diagnostics
+}
+
+// Parses tokens read from lexer, like Parse.
+// Tokens are read on demand, so the code does not need to be tokenized upfront.
+func (c *Compiler) ParseLexer(lexer *tokenizer.Lexer, prettyPrinting bool) (string, []diagnostic.Diagnostic) {
+	file, diagnostics := c.parseFile(lexer)
+	return sqf.Emit(file, prettyPrinting), diagnostics
+}
+
+// Parses tokens read from lexer into an abstract syntax tree,
+// which can be analysed and compiled to SQF using the sqf package.
+// Statements containing errors are left out of the tree.
+// Calls of undeclared functions are not checked, since other files can declare them, see CheckFunctionNames.
+func (c *Compiler) ParseAST(lexer *tokenizer.Lexer) (*ast.File, []diagnostic.Diagnostic) {
+	return c.parse(lexer)
+}
+
+// Parses tokens read from lexer into a lossless concrete syntax tree.
+// Enable trivia on the lexer to keep whitespace and comments, so that
+// the input can be regenerated from the tree, even if it contains errors.
+func (c *Compiler) ParseTree(lexer *tokenizer.Lexer) (*cst.Node, []diagnostic.Diagnostic) {
+	root := &cst.Node{Kind: cst.File}
+	c.tree = []*cst.Node{root}
+	_, diagnostics := c.parseFile(lexer)
+	root.Add(cst.NewLeaf(lexer.Next())) // end of file holding the remaining trivia
+	c.tree = nil
+
+	return root, diagnostics
+}
+
+func (c *Compiler) parse(tokens tokenSource) (*ast.File, []diagnostic.Diagnostic) {
+	file := &ast.File{Name: tokens.Peek(0).File, Stmts: make([]ast.Stmt, 0)}
+
+	if c.initParser(tokens) {
+		for {
+			file.Stmts = append(file.Stmts, c.parseStatements()...)
+
+			if c.end() {
+				break
+			}
+
+			// closing brace or case outside of block
+			c.report(diagnostic.UnexpectedToken, c.get(), "Parse error, unexpected '"+c.get().Token+"'")
+			c.next()
+		}
+	}
+
+	// comments at the end of the file
+	c.readComments(tokens.Peek(0))
+	file.Comments = c.comments
+
+	if lexer, ok := tokens.(*tokenizer.Lexer); ok {
+		c.diagnostics = append(c.diagnostics, lexer.Diagnostics()...)
 	}
 
-	for c.tokenIndex < len(token) {
-		c.parseBlock()
+	diagnostic.Sort(c.diagnostics)
+
+	return file, c.diagnostics
+}
+
+// Parses a whole file and checks the result, code embedded in strings is checked with the file.
+func (c *Compiler) parseFile(tokens tokenSource) (*ast.File, []diagnostic.Diagnostic) {
+	file, _ := c.parse(tokens)
+	c.diagnostics = append(c.diagnostics, CheckFunctionNames([]*ast.File{file})...)
+	diagnostic.Sort(c.diagnostics)
+
+	return file, c.diagnostics
+}
+
+// Warns about calls of functions not declared within any of the files,
+// if their name is similar to a build in function.
+// All files of a program must be checked together, since functions can be called by other files.
+func CheckFunctionNames(files []*ast.File) []diagnostic.Diagnostic {
+	declared := make(map[string]bool)
+	diagnostics := make([]diagnostic.Diagnostic, 0)
+
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			if function, ok := node.(*ast.Func); ok {
+				declared[strings.ToLower(function.Name.Name)] = true
+			}
+
+			return true
+		})
+	}
+
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.Call)
+
+			if !ok || declared[strings.ToLower(call.Name.Name)] {
+				return true
+			}
+
+			if suggestions := types.Suggest(call.Name.Name); len(suggestions) > 0 {
+				d := newError(diagnostic.UnknownFunction, call.Name.Token, "Unknown function '"+call.Name.Name+"'")
+				d.Severity = diagnostic.Warning
+				d.Suggestions = suggestions
+				diagnostics = append(diagnostics, d)
+			}
+
+			return true
+		})
+	}
+
+	return diagnostics
+}
+
+// Parses statements until the end of input, a closing brace or a switch case.
+func (c *Compiler) parseStatements() []ast.Stmt {
+	stmts := make([]ast.Stmt, 0)
+
+	for !c.end() && !c.accept("}") && !c.accept("case") && !c.accept("default") {
+		if stmt := c.parseStmtRecover(); stmt != nil {
+			stmts = append(stmts, stmt)
+		}
 	}
 
-	return c.out
+	return stmts
 }
 
-func (c *Compiler) parseBlock() {
-	if c.get().Preprocessor {
-		c.parsePreprocessor()
+// Parses a statement. On errors the parser skips to the end of the statement
+// and nil is returned, so that all errors within a file are found.
+func (c *Compiler) parseStmtRecover() (stmt ast.Stmt) {
+	depth := c.depth
+
+	defer func() {
+		if r := recover(); r != nil {
+			c.recoverError(r)
+			c.synchronize(depth)
+			stmt = nil
+		}
+	}()
+
+	return c.parseStmt()
+}
+
+// Parses statements enclosed in braces.
+func (c *Compiler) parseBlock() *ast.Block {
+	block := &ast.Block{Token: c.expect("{")}
+	block.Stmts = c.parseStatements()
+	block.End = c.expect("}")
+
+	return block
+}
+
+func (c *Compiler) parseStmt() ast.Stmt {
+	if c.get().Kind == tokenizer.Preprocessor {
+		return c.parsePreprocessor()
 	} else if c.accept("var") {
-		c.parseVar()
+		return c.parseVar()
 	} else if c.accept("if") {
-		c.parseIf()
+		return c.parseIf()
 	} else if c.accept("while") {
-		c.parseWhile()
+		return c.parseWhile()
 	} else if c.accept("switch") {
-		c.parseSwitch()
+		return c.parseSwitch()
 	} else if c.accept("for") {
-		c.parseFor()
+		return c.parseFor()
 	} else if c.accept("foreach") {
-		c.parseForeach()
-	} else if c.accept("func") {
-		c.parseFunction()
+		return c.parseForeach()
+	} else if c.accept("func") || c.acceptInline() {
+		return c.parseFunction()
 	} else if c.accept("return") {
-		c.parseReturn()
+		return c.parseReturn()
 	} else if c.accept("try") {
-		c.parseTryCatch()
+		return c.parseTryCatch()
 	} else if c.accept("exitwith") {
-		c.parseExitWith()
+		return c.parseExitWith()
 	} else if c.accept("waituntil") {
-		c.parseWaitUntil()
-	} else if c.accept("case") || c.accept("default") {
-		return
-	} else {
-		c.parseStatement()
+		return c.parseWaitUntil()
 	}
 
-	if !c.end() && !c.accept("}") {
-		c.parseBlock()
-	}
+	return c.parseStatement()
 }
 
-func (c *Compiler) parsePreprocessor() {
-	// we definitely want a new line before and after
-	c.appendOut(new_line+c.get().Token+new_line, false)
+func (c *Compiler) parsePreprocessor() *ast.Preprocessor {
+	c.open(cst.Preprocessor)
+	defer c.close()
+
+	token := c.get()
 	c.next()
+
+	return &ast.Preprocessor{Token: token, Text: token.Token}
 }
 
-func (c *Compiler) parseVar() {
-	c.expect("var")
-	c.appendOut(c.get().Token, false)
-	c.next()
+func (c *Compiler) parseVar() *ast.Var {
+	c.open(cst.Var)
+	defer c.close()
+
+	node := &ast.Var{Token: c.expect("var")}
+	node.Name = c.ident()
 
 	if c.accept("=") {
 		c.next()
-		c.appendOut(" = ", false)
-		c.parseExpression(true)
+		node.Value = c.parseExpression()
 	}
 
 	c.expect(";")
-	c.appendOut(";", true)
+
+	return node
 }
 
-func (c *Compiler) parseArray(out bool) string {
-	output := ""
-	c.expect("[")
-	output += "["
+func (c *Compiler) parseArray() *ast.Array {
+	c.open(cst.Array)
+	defer c.close()
+
+	node := &ast.Array{Token: c.expect("[")}
 
 	if !c.accept("]") {
-		output += c.parseExpression(false)
+		node.Elems = append(node.Elems, c.parseExpression())
 
 		for c.accept(",") {
 			c.next()
-			output += "," + c.parseExpression(false)
+			node.Elems = append(node.Elems, c.parseExpression())
 		}
 	}
 
 	c.expect("]")
-	output += "]"
-
-	if out {
-		c.appendOut(output, false)
-	}
 
-	return output
+	return node
 }
 
-func (c *Compiler) parseIf() {
-	c.expect("if")
-	c.appendOut("if (", false)
-	c.parseExpression(true)
-	c.appendOut(") then {", true)
-	c.expect("{")
-	c.parseBlock()
-	c.expect("}")
+func (c *Compiler) parseIf() *ast.If {
+	c.open(cst.If)
+	defer c.close()
+
+	node := &ast.If{Token: c.expect("if")}
+	node.Cond = c.parseExpression()
+	node.Then = c.parseBlock()
 
 	if c.accept("else") {
 		c.next()
-		c.expect("{")
-		c.appendOut("} else {", true)
-		c.parseBlock()
-		c.expect("}")
+		node.Else = c.parseBlock()
 	}
 
-	c.appendOut("};", true)
+	return node
 }
 
-func (c *Compiler) parseWhile() {
-	c.expect("while")
-	c.appendOut("while {", false)
-	c.parseExpression(true)
-	c.appendOut("} do {", true)
-	c.expect("{")
-	c.parseBlock()
-	c.expect("}")
-	c.appendOut("};", true)
+func (c *Compiler) parseWhile() *ast.While {
+	c.open(cst.While)
+	defer c.close()
+
+	node := &ast.While{Token: c.expect("while")}
+	node.Cond = c.parseExpression()
+	node.Body = c.parseBlock()
+
+	return node
 }
 
-func (c *Compiler) parseSwitch() {
-	c.expect("switch")
-	c.appendOut("switch (", false)
-	c.parseExpression(true)
-	c.appendOut(") do {", true)
+func (c *Compiler) parseSwitch() *ast.Switch {
+	c.open(cst.Switch)
+	defer c.close()
+
+	node := &ast.Switch{Token: c.expect("switch")}
+	node.Expr = c.parseExpression()
 	c.expect("{")
-	c.parseSwitchBlock()
+
+	for !c.accept("}") {
+		node.Cases = append(node.Cases, c.parseSwitchCase())
+	}
+
 	c.expect("}")
-	c.appendOut("};", true)
+
+	return node
 }
 
-func (c *Compiler) parseSwitchBlock() {
-	if c.accept("}") {
-		return
-	}
+func (c *Compiler) parseSwitchCase() *ast.Case {
+	c.open(cst.Case)
+	defer c.close()
+
+	node := &ast.Case{Token: c.get()}
 
 	if c.accept("case") {
 		c.next()
-		c.appendOut("case ", false)
-		c.parseExpression(true)
-		c.expect(":")
-		c.appendOut(":", true)
-
-		if !c.accept("case") && !c.accept("}") && !c.accept("default") {
-			c.appendOut("{", true)
-			c.parseBlock()
-			c.appendOut("};", true)
-		}
+		node.Expr = c.parseExpression()
 	} else if c.accept("default") {
 		c.next()
-		c.expect(":")
-		c.appendOut("default:", true)
+	} else {
+		c.unexpected(c.get())
+	}
 
-		if !c.accept("}") {
-			c.appendOut("{", true)
-			c.parseBlock()
-			c.appendOut("};", true)
-		}
+	c.expect(":")
+
+	if stmts := c.parseStatements(); len(stmts) > 0 {
+		node.Body = &ast.Block{Token: stmts[0].Pos(), Stmts: stmts}
 	}
 
-	c.parseSwitchBlock()
+	return node
 }
 
-func (c *Compiler) parseFor() {
-	c.expect("for")
-	c.appendOut("for [{", false)
+func (c *Compiler) parseFor() *ast.For {
+	c.open(cst.For)
+	defer c.close()
+
+	node := &ast.For{Token: c.expect("for")}
 
 	// var in first assignment is optional
 	if c.accept("var") {
 		c.next()
+		node.Var = true
 	}
 
-	c.parseExpression(true)
+	node.Init = c.parseExpression()
 	c.expect(";")
-	c.appendOut("}, {", false)
-	c.parseExpression(true)
+	node.Cond = c.parseExpression()
 	c.expect(";")
-	c.appendOut("}, {", false)
-	c.parseExpression(true)
-	c.appendOut("}] do {", true)
-	c.expect("{")
-	c.parseBlock()
-	c.expect("}")
-	c.appendOut("};", true)
+	node.Post = c.parseExpression()
+	node.Body = c.parseBlock()
+
+	return node
 }
 
-func (c *Compiler) parseForeach() {
-	c.expect("foreach")
-	element := c.get().Token
-	c.next()
+func (c *Compiler) parseForeach() *ast.Foreach {
+	c.open(cst.Foreach)
+	defer c.close()
+
+	node := &ast.Foreach{Token: c.expect("foreach")}
+	node.Elem = c.ident()
 	c.expect("=")
 	c.expect(">")
-	expr := c.parseExpression(false)
-	c.expect("{")
-	c.appendOut("{", true)
-	c.appendOut(element+" = _x;", true)
-	c.parseBlock()
-	c.expect("}")
-	c.appendOut("} forEach ("+expr+");", true)
+	node.Expr = c.parseExpression()
+	node.Body = c.parseBlock()
+
+	return node
 }
 
-func (c *Compiler) parseFunction() {
-	c.expect("func")
+func (c *Compiler) parseFunction() *ast.Func {
+	c.open(cst.Func)
+	defer c.close()
+
+	node := &ast.Func{}
+	first := c.get()
+
+	if c.acceptInline() {
+		node.Inline = true
+		c.next()
+	}
+
+	node.Token = c.expect("func")
+	node.Doc = c.docComment(first)
 
 	// check for build in function
 	if buildin := types.GetFunction(c.get().Token); buildin != nil {
-		panic(errors.New(c.get().Token + " is a build in function, choose a different name"))
+		c.report(diagnostic.BuildinRedeclared, c.get(), c.get().Token+" is a build in function, choose a different name")
 	}
 
-	c.appendOut(c.get().Token+" = {", true)
-	c.next()
+	node.Name = c.ident()
 	c.expect("(")
-	c.parseFunctionParameter()
+	node.Params = c.parseFunctionParameter()
 	c.expect(")")
-	c.expect("{")
-	c.parseBlock()
-	c.expect("}")
-	c.appendOut("};", true)
-}
+	node.Body = c.parseBlock()
 
-func (c *Compiler) parseFunctionParameter() {
-	// empty parameter list
-	if c.accept("{") {
-		return
-	}
+	return node
+}
 
-	c.appendOut("params [", false)
+func (c *Compiler) parseFunctionParameter() []*ast.Param {
+	params := make([]*ast.Param, 0)
 
 	for !c.accept(")") {
-		name := c.get().Token
-		c.next()
+		param := &ast.Param{Name: c.ident()}
 
 		if c.accept("=") {
 			c.next()
-			value := c.get().Token
-			c.next()
-			c.appendOut("[\""+name+"\","+value+"]", false)
-		} else {
-			c.appendOut("\""+name+"\"", false)
+			param.Default = c.parseExpression()
 		}
 
+		params = append(params, param)
+
 		if !c.accept(")") {
 			c.expect(",")
-			c.appendOut(",", false)
 		}
 	}
 
-	c.appendOut("];", true)
+	return params
 }
 
-func (c *Compiler) parseReturn() {
-	c.expect("return")
-	c.appendOut("return ", false)
-	c.parseExpression(true)
+func (c *Compiler) parseReturn() *ast.Return {
+	c.open(cst.Return)
+	defer c.close()
+
+	node := &ast.Return{Token: c.expect("return")}
+	node.Value = c.parseExpression()
 	c.expect(";")
-	c.appendOut(";", true)
+
+	return node
 }
 
-func (c *Compiler) parseTryCatch() {
-	c.expect("try")
-	c.expect("{")
-	c.appendOut("try {", true)
-	c.parseBlock()
-	c.expect("}")
+func (c *Compiler) parseTryCatch() *ast.Try {
+	c.open(cst.Try)
+	defer c.close()
+
+	node := &ast.Try{Token: c.expect("try")}
+	node.Body = c.parseBlock()
 	c.expect("catch")
-	c.expect("{")
-	c.appendOut("} catch {", true)
-	c.parseBlock()
-	c.expect("}")
-	c.appendOut("};", true)
+	node.Catch = c.parseBlock()
+
+	return node
 }
 
-func (c *Compiler) parseExitWith() {
-	c.expect("exitwith")
-	c.expect("{")
-	c.appendOut("if (true) exitWith {", true)
-	c.parseBlock()
-	c.expect("}")
-	c.appendOut("};", true)
+func (c *Compiler) parseExitWith() *ast.ExitWith {
+	c.open(cst.ExitWith)
+	defer c.close()
+
+	node := &ast.ExitWith{Token: c.expect("exitwith")}
+	node.Body = c.parseBlock()
+
+	return node
 }
 
-func (c *Compiler) parseWaitUntil() {
-	c.expect("waituntil")
+func (c *Compiler) parseWaitUntil() *ast.WaitUntil {
+	c.open(cst.WaitUntil)
+	defer c.close()
+
+	node := &ast.WaitUntil{Token: c.expect("waituntil")}
 	c.expect("(")
-	c.appendOut("waitUntil {", false)
-	c.parseExpression(true)
+	node.Exprs = append(node.Exprs, c.parseExpression())
 
 	if c.accept(";") {
 		c.next()
-		c.appendOut(";", false)
-		c.parseExpression(true)
+		node.Exprs = append(node.Exprs, c.parseExpression())
 	}
 
 	c.expect(")")
 	c.expect(";")
-	c.appendOut("};", true)
+
+	return node
 }
 
-func (c *Compiler) parseInlineCode() string {
-	c.expect("code")
+func (c *Compiler) parseInlineCode() *ast.Code {
+	node := &ast.Code{Token: c.expect("code")}
 	c.expect("(")
-
-	code := c.get().Token
+	node.Source = c.get()
+	node.Body = &ast.Block{Token: node.Source, Stmts: make([]ast.Stmt, 0)}
 	c.next()
-	output := "{}"
 
-	if len(code) > 2 {
+	if len(node.Source.Token) > 2 {
+		lexer := tokenizer.NewLexer(strings.NewReader(tokenizer.Unquote(node.Source.Token)), node.Source.File)
+		lexer.SetPosition(positionIn(node.Source, 1))
 		compiler := Compiler{}
-		output = "{" + compiler.Parse(tokenizer.Tokenize([]byte(code[1:len(code)-1]), true), false) + "}"
+		body, diagnostics := compiler.parse(lexer)
+		node.Body.Stmts = body.Stmts
+		c.diagnostics = append(c.diagnostics, diagnostics...)
 	}
 
 	c.expect(")")
 
-	return output
+	return node
 }
 
-// Everything that does not start with a keyword.
-func (c *Compiler) parseStatement() {
-	// empty block
-	if c.accept("}") || c.accept("case") || c.accept("default") {
-		return
-	}
-
-	// variable or function name
-	name := c.get().Token
+// Parses an interpolated string like $"{_a} killed {_b}",
+// which is compiled to format ["%1 killed %2", _a, _b].
+func (c *Compiler) parseInterpolation() *ast.Interpolation {
+	token := c.get()
 	c.next()
 
-	if c.accept("=") {
-		c.appendOut(name, false)
-		c.parseAssignment()
-	} else {
-		c.parseFunctionCall(true, name)
-		c.expect(";")
-		c.appendOut(";", true)
+	node := &ast.Interpolation{Token: token}
+
+	// unterminated strings are reported by the lexer
+	if len(token.Token) < 3 || token.Token[len(token.Token)-1] != '"' {
+		return node
 	}
 
-	if !c.end() {
-		c.parseBlock()
+	str := token.Token[2 : len(token.Token)-1]
+
+	for i := 0; i < len(str); i++ {
+		if (str[i] == '{' || str[i] == '}') && i+1 < len(str) && str[i+1] == str[i] {
+			node.Format += str[i : i+1]
+			i++
+		} else if str[i] == '{' {
+			end := interpolationEnd(str, i)
+
+			if end == -1 {
+				c.report(diagnostic.InvalidInterpolation, token, "Parse error, missing '}' in interpolated string")
+				break
+			}
+
+			if expr := c.parseInterpolationExpression(str[i+1:end], token, i+3); expr != nil {
+				node.Args = append(node.Args, expr)
+				node.Format += "%" + strconv.Itoa(len(node.Args))
+			}
+
+			i = end
+		} else if str[i] == '}' {
+			c.report(diagnostic.InvalidInterpolation, token, "Parse error, unexpected '}' in interpolated string")
+		} else if str[i] == '%' {
+			// a literal % could be interpreted as placeholder by format
+			node.Args = append(node.Args, &ast.Literal{Token: token, Kind: tokenizer.String, Value: "\"%\""})
+			node.Format += "%" + strconv.Itoa(len(node.Args))
+		} else {
+			node.Format += str[i : i+1]
+		}
 	}
-}
 
-func (c *Compiler) parseAssignment() {
-	c.expect("=")
-	c.appendOut(" = ", false)
-	c.parseExpression(true)
-	c.expect(";")
-	c.appendOut(";", true)
+	return node
 }
 
-func (c *Compiler) parseFunctionCall(out bool, name string) string {
-	output := ""
+// Returns the index of the brace closing the expression opened at i,
+// or -1 if it is not closed.
+func interpolationEnd(str string, i int) int {
+	depth, quote := 0, byte(0)
+
+	for ; i < len(str); i++ {
+		if quote != 0 {
+			if str[i] == quote {
+				quote = 0
+			}
+		} else if tokenizer.IsString(str[i : i+1]) {
+			quote = str[i]
+		} else if str[i] == '{' {
+			depth++
+		} else if str[i] == '}' {
+			depth--
+
+			if depth == 0 {
+				return i
+			}
+		}
+	}
 
-	c.expect("(")
-	paramsStr, paramCount := c.parseParameter(false)
-	c.expect(")")
+	return -1
+}
 
-	// buildin function
-	buildin := types.GetFunction(name)
-	
-	if buildin != nil {
-		if buildin.Type == types.NULL {
-			output = name
-		} else if buildin.Type == types.UNARY {
-			output = c.parseUnaryFunction(name, paramsStr, paramCount)
-		} else {
-			output = c.parseBinaryFunction(name, paramsStr, buildin, paramCount)
+// Parses an expression embedded in an interpolated string, starting at byte index i of the token.
+// Returns nil if the expression contains errors.
+func (c *Compiler) parseInterpolationExpression(expr string, token tokenizer.Token, i int) (output ast.Expr) {
+	lexer := tokenizer.NewLexer(strings.NewReader(expr), token.File)
+	lexer.SetPosition(positionIn(token, i))
+	compiler := Compiler{}
+
+	defer func() {
+		if r := recover(); r != nil {
+			compiler.recoverError(r)
+			output = nil
 		}
-	} else {
-		output = "[" + paramsStr + "] call " + name
+
+		c.diagnostics = append(c.diagnostics, compiler.diagnostics...)
+		c.diagnostics = append(c.diagnostics, lexer.Diagnostics()...)
+	}()
+
+	if !compiler.initParser(lexer) {
+		c.report(diagnostic.InvalidInterpolation, token, "Parse error, empty expression in interpolated string")
+		return nil
 	}
 
-	if out {
-		c.appendOut(output, false)
+	output = compiler.parseExpression()
+
+	if !compiler.end() {
+		compiler.fail(diagnostic.InvalidInterpolation, compiler.get(), "Parse error, unexpected '"+compiler.get().Token+"' in interpolated string")
 	}
 
 	return output
 }
 
-func (c *Compiler) parseUnaryFunction(name, paramsStr string, paramCount int) string {
-	output := ""
+// Returns the position of byte index i within the token.
+func positionIn(token tokenizer.Token, i int) diagnostic.Position {
+	pos := diagnostic.Position{Line: token.Line, Column: token.Column, Offset: token.Start + i}
 
-	if paramCount == 1 {
-		output = name + " " + paramsStr
-	} else {
-		output = name + " [" + paramsStr + "]"
+	for _, c := range token.Token[:i] {
+		if c == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
 	}
 
-	return output
+	return pos
 }
 
-func (c *Compiler) parseBinaryFunction(name string, leftParamsStr string, buildin *types.FunctionType, paramCount int) string {
-	output := ""
+// Everything that does not start with a keyword.
+func (c *Compiler) parseStatement() ast.Stmt {
+	c.open(cst.Statement)
+	defer c.close()
 
-	c.next()
-	rightParamsStr, rightParamCount := c.parseParameter(false)
+	// variable or function name
+	name := c.ident()
+
+	if c.accept("=") {
+		c.next()
+		node := &ast.Assign{Name: name, Value: c.parseExpression()}
+		c.expect(";")
+
+		return node
+	}
+
+	var output ast.Expr = name
+
+	if !c.accept(".") {
+		output = c.parseFunctionCall(name)
+	}
+
+	for c.accept(".") {
+		output = c.parseMethodCall(output)
+	}
+
+	c.expect(";")
+
+	return &ast.ExprStmt{X: output}
+}
+
+func (c *Compiler) parseFunctionCall(name *ast.Ident) ast.Expr {
+	c.expect("(")
+	params := c.parseParameter()
 	c.expect(")")
 
-	if paramCount > 1 {
-		leftParamsStr = "[" + leftParamsStr + "]"
+	// buildin function
+	buildin := types.GetFunction(name.Name)
+
+	if buildin == nil {
+		return &ast.Call{Name: name, Args: params}
 	}
 
-	if rightParamCount > 1 {
-		rightParamsStr = "[" + rightParamsStr + "]"
+	c.canonicalName(name, buildin)
+
+	if buildin.Type == types.NULL {
+		return &ast.BuiltinCall{Name: name, Type: ast.NullCall, Right: params}
+	} else if buildin.Type == types.UNARY {
+		return &ast.BuiltinCall{Name: name, Type: ast.UnaryCall, Right: params}
 	}
 
-	if paramCount > 0 {
-		output = leftParamsStr + " " + name + " " + rightParamsStr
+	// binary build in functions take their right parameters in a second pair of parentheses
+	c.next()
+	right := c.parseParameter()
+	c.expect(")")
+
+	return &ast.BuiltinCall{Name: name, Type: ast.BinaryCall, Left: params, Right: right}
+}
+
+// Parses the method call syntax for binary build in functions,
+// receiver.name(params) is compiled to "receiver name params".
+func (c *Compiler) parseMethodCall(receiver ast.Expr) ast.Expr {
+	c.expect(".")
+	name := c.ident()
+	c.expect("(")
+	params := c.parseParameter()
+	c.expect(")")
+
+	buildin := types.GetFunction(name.Name)
+
+	if buildin == nil || buildin.Type != types.BINARY {
+		c.report(diagnostic.NotBinaryBuildin, name.Token, name.Name+" is not a binary build in function, it cannot be called on "+sqf.Emit(receiver, false))
+
+		if buildin == nil {
+			c.diagnostics[len(c.diagnostics)-1].Suggestions = types.Suggest(name.Name)
+		}
 	} else {
-		output = name + " " + rightParamsStr
+		c.canonicalName(name, buildin)
+
+		if len(params) == 0 {
+			c.report(diagnostic.MissingParameter, name.Token, "Binary build in function "+name.Name+" called on "+sqf.Emit(receiver, false)+" requires at least one parameter")
+		}
 	}
 
-	return output
+	return &ast.BuiltinCall{Name: name, Type: ast.BinaryCall, Left: []ast.Expr{receiver}, Right: params, Method: true}
+}
+
+// Replaces the name of a build in function by its spelling on the wiki, like getpos by getPos.
+// Names spelled differently in source code are reported.
+func (c *Compiler) canonicalName(name *ast.Ident, buildin *types.FunctionType) {
+	if buildin.Canonical == "" || buildin.Canonical == name.Name {
+		return
+	}
+
+	d := newError(diagnostic.BuildinSpelling, name.Token, "Build in function "+name.Name+" is spelled "+buildin.Canonical)
+	d.Severity = diagnostic.Warning
+	d.Suggestions = []string{buildin.Canonical}
+	c.diagnostics = append(c.diagnostics, d)
+	name.Name = buildin.Canonical
 }
 
-func (c *Compiler) parseParameter(out bool) (string, int) {
-	output := ""
-	count := 0
+func (c *Compiler) parseParameter() []ast.Expr {
+	params := make([]ast.Expr, 0)
 
 	for !c.accept(")") {
-		expr := c.parseExpression(out)
-		output += expr
-		count++
+		params = append(params, c.parseExpression())
 
 		if !c.accept(")") {
 			c.expect(",")
-			output += ", "
 		}
 	}
 
-	if out {
-		c.appendOut(output, false)
+	return params
+}
+
+// Parses an expression. Operators from lowest to highest precedence:
+// assignment (=), or (||, or), and (&&, and), comparison (== != < > <= >=),
+// binary commands (in, ===, !==), arithmetic (+ -) and factors (* /).
+func (c *Compiler) parseExpression() ast.Expr {
+	c.open(cst.Expression)
+	defer c.close()
+
+	output := c.parseOr()
+
+	// assignment, used within for loops and waituntil
+	if c.accept("=") {
+		operator := c.get()
+		c.next()
+		output = &ast.Binary{X: output, Op: "=", OpToken: operator, Y: c.parseExpression()}
 	}
 
-	return output, count
+	return output
 }
 
-func (c *Compiler) parseExpression(out bool) string {
-	output := c.parseArith()
+func (c *Compiler) parseOr() ast.Expr {
+	output := c.parseAnd()
 
-	for c.accept("<") || c.accept(">") || c.accept("&") || c.accept("|") || c.accept("=") || c.accept("!") {
-		if c.accept("<") {
-			output += "<"
-			c.next()
-		} else if c.accept(">") {
-			output += ">"
-			c.next()
-		} else if c.accept("&") {
-			c.next()
-			c.expect("&")
-			output += "&&"
-		} else if c.accept("|") {
-			c.next()
-			c.expect("|")
-			output += "||"
-		} else if c.accept("=") {
-			output += "="
+	for (c.accept("|") && c.seek("|")) || c.accept("or") {
+		operator := c.get()
+
+		if c.accept("|") {
 			c.next()
-		} else {
+			operator.Token = "||"
+		}
+
+		c.next()
+		output = &ast.Binary{X: output, Op: operator.Token, OpToken: operator, Y: c.parseAnd()}
+	}
+
+	return output
+}
+
+func (c *Compiler) parseAnd() ast.Expr {
+	output := c.parseComparison()
+
+	for (c.accept("&") && c.seek("&")) || c.accept("and") {
+		operator := c.get()
+
+		if c.accept("&") {
 			c.next()
-			c.expect("=")
-			output += "!="
+			operator.Token = "&&"
 		}
 
+		c.next()
+		output = &ast.Binary{X: output, Op: operator.Token, OpToken: operator, Y: c.parseComparison()}
+	}
+
+	return output
+}
+
+func (c *Compiler) parseComparison() ast.Expr {
+	output := c.parseBinaryCommand()
+
+	for c.accept("<") || c.accept(">") || (c.accept("=") && c.seek("=")) || (c.accept("!") && c.seek("=")) {
+		operator := c.get()
+		c.next()
+
 		if c.accept("=") {
-			output += "="
+			operator.Token += "="
 			c.next()
 		}
 
-		output += c.parseExpression(false)
+		output = &ast.Binary{X: output, Op: operator.Token, OpToken: operator, Y: c.parseBinaryCommand()}
 	}
 
-	if out {
-		c.appendOut(output, false)
+	return output
+}
+
+func (c *Compiler) parseBinaryCommand() ast.Expr {
+	output := c.parseArith()
+
+	for c.accept("in") || c.isStrictEquality() {
+		operator := c.get()
+
+		if c.accept("in") {
+			c.next()
+		} else {
+			operator.Token += "=="
+			c.next()
+			c.next()
+			c.next()
+		}
+
+		output = &ast.Binary{X: output, Op: operator.Token, OpToken: operator, Y: c.parseArith()}
 	}
 
 	return output
 }
 
-func (c *Compiler) parseIdentifier() string {
-	output := ""
+// Returns true if the next tokens form === or !==.
+func (c *Compiler) isStrictEquality() bool {
+	return (c.accept("=") || c.accept("!")) && c.seek("=") && c.lookahead("=", 2)
+}
+
+func (c *Compiler) parseIdentifier() ast.Expr {
+	token := c.get()
 
 	if c.accept("code") {
-		output += c.parseInlineCode()
-	} else if c.seek("(") && !c.accept("!") && !c.accept("-") {
-		name := c.get().Token
+		return c.parseInlineCode()
+	} else if token.Kind == tokenizer.String && token.Token[0] == '$' {
+		return c.parseInterpolation()
+	} else if c.accept("!") || c.accept("-") || c.accept("not") {
 		c.next()
-		output = "(" + c.parseFunctionCall(false, name) + ")"
+		return &ast.Unary{Token: token, Op: token.Token, X: c.parseTerm()}
+	} else if c.seek("(") {
+		c.open(cst.Call)
+		defer c.close()
+
+		return c.parseFunctionCall(c.ident())
 	} else if c.accept("[") {
-		output += c.parseArray(false)
+		return c.parseArray()
 	} else if c.seek("[") {
-		output += "(" + c.get().Token
-		c.next()
+		node := &ast.Index{X: c.ident()}
 		c.expect("[")
-		output += " select (" + c.parseExpression(false) + "))"
+		node.Index = c.parseExpression()
 		c.expect("]")
-	} else if c.accept("!") || c.accept("-") {
-		output = c.get().Token
-		c.next()
-		output += c.parseTerm()
-	} else {
-		output = c.get().Token
-		c.next()
+
+		return node
 	}
 
-	return output
+	if token.Kind == tokenizer.Operator {
+		c.unexpected(token)
+	}
+
+	c.next()
+
+	if token.Kind == tokenizer.Number || token.Kind == tokenizer.String || c.tokenEqual("true", token) || c.tokenEqual("false", token) {
+		return &ast.Literal{Token: token, Kind: token.Kind, Value: token.Token}
+	}
+
+	return &ast.Ident{Token: token, Name: token.Token}
 }
 
-func (c *Compiler) parseTerm() string {
+func (c *Compiler) parseTerm() ast.Expr {
+	var output ast.Expr
+
 	if c.accept("(") {
-		c.expect("(")
-		output := "(" + c.parseExpression(false) + ")"
+		node := &ast.Paren{Token: c.expect("(")}
+		node.X = c.parseExpression()
 		c.expect(")")
+		output = node
+	} else {
+		output = c.parseIdentifier()
+	}
 
-		return output
+	for c.accept(".") {
+		output = c.parseMethodCall(output)
 	}
 
-	return c.parseIdentifier()
+	return output
 }
 
-func (c *Compiler) parseFactor() string {
+func (c *Compiler) parseFactor() ast.Expr {
 	output := c.parseTerm()
 
 	for c.accept("*") || c.accept("/") { // TODO: modulo?
-		if c.accept("*") {
-			output += "*"
-		} else {
-			output += "/"
-		}
-
+		operator := c.get()
 		c.next()
-		output += c.parseExpression(false)
+		output = &ast.Binary{X: output, Op: operator.Token, OpToken: operator, Y: c.parseTerm()}
 	}
 
 	return output
 }
 
-func (c *Compiler) parseArith() string {
+func (c *Compiler) parseArith() ast.Expr {
 	output := c.parseFactor()
 
 	for c.accept("+") || c.accept("-") {
-		if c.accept("+") {
-			output += "+"
-		} else {
-			output += "-"
-		}
-
+		operator := c.get()
 		c.next()
-		output += c.parseExpression(false)
+		output = &ast.Binary{X: output, Op: operator.Token, OpToken: operator, Y: c.parseFactor()}
 	}
 
 	return output
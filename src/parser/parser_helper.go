@@ -1,89 +1,298 @@
 package parser
 
 import (
-	"errors"
-	"strconv"
+	"ast"
+	"cst"
+	"diagnostic"
+	"strings"
 	"tokenizer"
+	"unicode/utf8"
 )
 
+var statementKeywords = []string{"var", "if", "while", "switch", "for", "foreach", "func", "return", "try", "exitwith", "waituntil"}
+
 type Compiler struct {
-	tokens     []tokenizer.Token
-	tokenIndex int
-	out        string
-	offset     int
-	pretty     bool
+	tokens      tokenSource
+	depth       int         // number of open braces
+	tree        []*cst.Node // stack of open nodes, if a syntax tree is built
+	comments    []*ast.Comment
+	diagnostics []diagnostic.Diagnostic
 }
 
-// Initilizes the parser.
-func (c *Compiler) initParser(token []tokenizer.Token, prettyPrinting bool) bool {
-	if len(token) == 0 {
-		return false
+// Provides the tokens to parse, implemented by tokenizer.Lexer.
+type tokenSource interface {
+	Next() tokenizer.Token
+	Peek(n int) tokenizer.Token
+}
+
+// Provides tokens from a slice.
+type tokenList struct {
+	tokens []tokenizer.Token
+	index  int
+}
+
+func (l *tokenList) Next() tokenizer.Token {
+	token := l.Peek(0)
+	l.index++
+	return token
+}
+
+func (l *tokenList) Peek(n int) tokenizer.Token {
+	if l.index+n < len(l.tokens) {
+		return l.tokens[l.index+n]
 	}
 
-	c.tokens = token
-	c.tokenIndex = 0
-	c.out = ""
-	c.offset = 0
-	c.pretty = prettyPrinting
+	return tokenizer.Token{Kind: tokenizer.EOF}
+}
+
+// Initilizes the parser.
+func (c *Compiler) initParser(tokens tokenSource) bool {
+	c.tokens = tokens
+	c.depth = 0
+	c.comments = nil
+	c.diagnostics = nil
 
-	return true
+	return tokens.Peek(0).Kind != tokenizer.EOF
 }
 
 // Returns true, if current token matches expected one.
 // Does not throw parse errors and checks if token is available.
 func (c *Compiler) accept(token string) bool {
-	return c.tokenIndex < len(c.tokens) && c.tokenEqual(token, c.get())
+	return c.tokenEqual(token, c.tokens.Peek(0))
 }
 
 // Hard version of "accept".
-// Throws if current token does not match expected one.
-func (c *Compiler) expect(token string) {
-	if !c.tokenEqual(token, c.get()) {
-		panic(errors.New("Parse error, expected '" + token + "' but was '" + c.get().Token + "' in line " + strconv.Itoa(c.get().Line) + " at " + strconv.Itoa(c.get().Column)))
+// Throws if current token does not match expected one, returns the token otherwise.
+func (c *Compiler) expect(token string) tokenizer.Token {
+	current := c.get()
+
+	if !c.tokenEqual(token, current) {
+		c.fail(diagnostic.ExpectedToken, current, "Parse error, expected '"+token+"' but was '"+current.Token+"'")
 	}
 
 	c.next()
+	return current
 }
 
 // Returns true, if the next token matches expected one.
 // Does not throw parse errors and checks if token is available.
 func (c *Compiler) seek(token string) bool {
-	if c.tokenIndex+1 >= len(c.tokens) {
-		return false
-	}
+	return c.lookahead(token, 1)
+}
+
+// Returns true, if the current token is the inline modifier of a function.
+// Inline is only a keyword in front of func, so that it can still be used as a name.
+func (c *Compiler) acceptInline() bool {
+	token := c.get()
+	return token.Kind == tokenizer.Identifier && token.Token == "inline" && c.seek("func")
+}
 
-	return c.tokenEqual(token, c.tokens[c.tokenIndex+1])
+// Returns true, if the token n positions ahead matches expected one.
+// Does not throw parse errors and checks if token is available.
+func (c *Compiler) lookahead(token string, n int) bool {
+	return c.tokenEqual(token, c.tokens.Peek(n))
 }
 
-// Increases token counter, so that the next token is compared.
+// Moves on to the next token.
+// If a syntax tree is built, the token is added to the current node.
 func (c *Compiler) next() {
-	c.tokenIndex++
+	token := c.tokens.Next()
+
+	if c.tokenEqual("{", token) {
+		c.depth++
+	} else if c.tokenEqual("}", token) {
+		c.depth--
+	}
+
+	if len(c.tree) > 0 {
+		c.tree[len(c.tree)-1].Add(cst.NewLeaf(token))
+	}
+
+	c.readComments(token)
+}
+
+// Collects the comments within the trivia of a token, together with their position.
+// Leading trivia ends at the start of the token, trailing trivia starts at its end.
+func (c *Compiler) readComments(token tokenizer.Token) {
+	pos := tokenizer.Token{Line: token.Line, Column: 1, Start: token.Start, File: token.File}
+
+	for _, trivia := range token.Leading {
+		pos.Start -= len(trivia.Text)
+		pos.Line -= strings.Count(trivia.Text, "\n")
+	}
+
+	pos = c.addComments(token.Leading, pos)
+	c.addComments(token.Trailing, advance(pos, token.Token))
+}
+
+// Adds the comments within trivia starting at given position, returns the position behind the trivia.
+func (c *Compiler) addComments(trivia []tokenizer.Trivia, pos tokenizer.Token) tokenizer.Token {
+	for _, t := range trivia {
+		if t.Kind == tokenizer.Comment {
+			comment := pos
+			comment.End = pos.Start + len(t.Text)
+			c.comments = append(c.comments, &ast.Comment{Token: comment, Text: t.Text})
+		}
+
+		pos = advance(pos, t.Text)
+	}
+
+	return pos
+}
+
+// Returns the position behind text starting at given position.
+func advance(pos tokenizer.Token, text string) tokenizer.Token {
+	pos.Start += len(text)
+
+	if i := strings.LastIndexByte(text, '\n'); i != -1 {
+		pos.Line += strings.Count(text, "\n")
+		pos.Column = utf8.RuneCountInString(text[i+1:]) + 1
+	} else {
+		pos.Column += utf8.RuneCountInString(text)
+	}
+
+	return pos
+}
+
+// Returns the doc comment /** ... */ on the line in front of the token, which must have been read.
+func (c *Compiler) docComment(token tokenizer.Token) *ast.Comment {
+	end, newLines := token.Start, 0
+
+	for i := len(token.Leading) - 1; i >= 0; i-- {
+		trivia := token.Leading[i]
+
+		if trivia.Kind == tokenizer.Newline {
+			newLines++
+		} else if trivia.Kind == tokenizer.Comment {
+			break
+		}
+
+		end -= len(trivia.Text)
+	}
+
+	if newLines > 1 {
+		return nil
+	}
+
+	for _, comment := range c.comments {
+		if comment.Token.End == end && comment.Doc() {
+			return comment
+		}
+	}
+
+	return nil
+}
+
+// Starts a new node within the current node of the syntax tree, if built.
+func (c *Compiler) open(kind string) {
+	if len(c.tree) == 0 {
+		return
+	}
+
+	node := &cst.Node{Kind: kind}
+	c.tree[len(c.tree)-1].Add(node)
+	c.tree = append(c.tree, node)
+}
+
+// Ends the current node of the syntax tree.
+func (c *Compiler) close() {
+	if len(c.tree) > 1 {
+		c.tree = c.tree[:len(c.tree)-1]
+	}
+}
+
+// Returns the current token as identifier and moves on.
+func (c *Compiler) ident() *ast.Ident {
+	token := c.get()
+	c.next()
+
+	return &ast.Ident{Token: token, Name: token.Token}
 }
 
 // Returns current token or throws, if no more tokens are available.
 func (c *Compiler) get() tokenizer.Token {
-	if c.tokenIndex >= len(c.tokens) {
-		panic(errors.New("No more tokens"))
+	token := c.tokens.Peek(0)
+
+	if token.Kind == tokenizer.EOF {
+		c.fail(diagnostic.UnexpectedEnd, token, "Parse error, unexpected end of file")
 	}
 
-	return c.tokens[c.tokenIndex]
+	return token
 }
 
 // Returns true if the end of input code was reached.
 func (c *Compiler) end() bool {
-	return c.tokenIndex == len(c.tokens)
+	return c.tokens.Peek(0).Kind == tokenizer.EOF
 }
 
-// Checks if two strings match.
+// Checks if the token is the expected keyword or operator.
+// Identifiers, numbers, strings and preprocessor commands never match.
 func (c *Compiler) tokenEqual(a string, b tokenizer.Token) bool {
-	return a == b.Token
+	return (b.Kind == tokenizer.Keyword || b.Kind == tokenizer.Operator) && a == b.Token
+}
+
+// Throws an error for unexpected token.
+func (c *Compiler) unexpected(token tokenizer.Token) {
+	c.fail(diagnostic.UnexpectedToken, token, "Parse error, unexpected '"+token.Token+"'")
+}
+
+// Throws an error for given token, which is recovered from at the end of the statement.
+func (c *Compiler) fail(code string, token tokenizer.Token, msg string) {
+	panic(newError(code, token, msg))
 }
 
-// Appends the output string to current SQF code output.
-func (c *Compiler) appendOut(str string, newLine bool) {
-	c.out += str
+// Reports an error for given token without interrupting the parser.
+func (c *Compiler) report(code string, token tokenizer.Token, msg string) {
+	c.diagnostics = append(c.diagnostics, newError(code, token, msg))
+}
+
+// Recovers from an error thrown by fail, the error is added to the diagnostics.
+// Other panics are passed on.
+func (c *Compiler) recoverError(r interface{}) {
+	d, ok := r.(diagnostic.Diagnostic)
 
-	if newLine && c.pretty {
-		c.out += "\r\n"
+	if !ok {
+		panic(r)
 	}
+
+	c.diagnostics = append(c.diagnostics, d)
+}
+
+// Skips tokens until the end of the statement started at given brace depth,
+// which is a semicolon, a closing brace of an enclosing block, the end of a block statement
+// or a keyword starting the next statement.
+func (c *Compiler) synchronize(depth int) {
+	for !c.end() {
+		if c.depth == depth && (c.accept("}") || c.accept("case") || c.accept("default") || c.isStatementKeyword()) {
+			return
+		}
+
+		semicolon := c.depth == depth && c.accept(";")
+		closing := c.accept("}")
+		c.next()
+
+		if semicolon || (closing && c.depth == depth) {
+			return
+		}
+	}
+}
+
+// Returns true if the current token is a keyword starting a statement.
+func (c *Compiler) isStatementKeyword() bool {
+	for _, keyword := range statementKeywords {
+		if c.accept(keyword) {
+			return true
+		}
+	}
+
+	return c.tokens.Peek(0).Kind == tokenizer.Preprocessor
+}
+
+func newError(code string, token tokenizer.Token, msg string) diagnostic.Diagnostic {
+	return diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		File:     token.File,
+		Range:    token.Range(),
+		Message:  msg,
+		Code:     code}
 }
@@ -1,8 +1,15 @@
 package parser_test
 
 import (
+	"ast"
+	"bytes"
+	"cst"
+	"diagnostic"
 	"io/ioutil"
+	"os"
 	"parser"
+	"path/filepath"
+	"strings"
 	"testing"
 	"tokenizer"
 	"types"
@@ -14,7 +21,7 @@ const (
 
 func TestParserDeclaration(t *testing.T) {
 	got := getCompiled(t, "../../test/tokenizer_var.asl")
-	want := "x = 1;\r\narray = [1,2,3];\r\n"
+	want := "x = 1;\r\narray = [1, 2, 3];\r\n"
 
 	equal(t, got, want)
 }
@@ -28,7 +35,7 @@ func TestParserAssignment(t *testing.T) {
 
 func TestParserIf(t *testing.T) {
 	got := getCompiled(t, "../../test/tokenizer_if.asl")
-	want := "if (a<b) then {\r\n};\r\n"
+	want := "if (a < b) then {\r\n};\r\n"
 
 	equal(t, got, want)
 }
@@ -42,28 +49,28 @@ func TestParserWhile(t *testing.T) {
 
 func TestParserFor(t *testing.T) {
 	got := getCompiled(t, "../../test/tokenizer_for.asl")
-	want := "for [{i=0}, {i<100}, {i=i+1}] do {\r\n};\r\n"
+	want := "for [{i = 0}, {i < 100}, {i = i + 1}] do {\r\n};\r\n"
 
 	equal(t, got, want)
 }
 
 func TestParserForeach(t *testing.T) {
 	got := getCompiled(t, "../../test/tokenizer_foreach.asl")
-	want := "{\r\nunit = _x;\r\n} forEach (allUnits);\r\n"
+	want := "{\r\n    unit = _x;\r\n} forEach (allUnits);\r\n"
 
 	equal(t, got, want)
 }
 
 func TestParserSwitch(t *testing.T) {
 	got := getCompiled(t, "../../test/tokenizer_switch.asl")
-	want := "switch (x) do {\r\ncase 1:\r\n{\r\nx = 1;\r\n};\r\ncase 2:\r\n{\r\nx = 2;\r\n};\r\ndefault:\r\n{\r\nx = 3;\r\n};\r\n};\r\n"
+	want := "switch (x) do {\r\n    case 1: {\r\n        x = 1;\r\n    };\r\n    case 2: {\r\n        x = 2;\r\n    };\r\n    default: {\r\n        x = 3;\r\n    };\r\n};\r\n"
 
 	equal(t, got, want)
 }
 
 func TestParserFunction(t *testing.T) {
 	got := getCompiled(t, "../../test/tokenizer_func.asl")
-	want := "TestFunction = {\r\nparams [\"param0\",\"param1\"];\r\nreturn true;\r\n};\r\n"
+	want := "TestFunction = {\r\n    params [\"param0\", \"param1\"];\r\n    return true;\r\n};\r\n"
 
 	equal(t, got, want)
 }
@@ -77,21 +84,21 @@ func TestParserAssignResult(t *testing.T) {
 
 func TestParserExpression(t *testing.T) {
 	got := getCompiled(t, "../../test/parser_expression.asl")
-	want := "x = -(1+(2+3))/(6*(someVariable+99-100))-(20)+!anotherVariable+([] call foo);\r\n"
+	want := "x = -(1 + (2 + 3)) / (6 * (someVariable + 99 - 100)) - (20) + !anotherVariable + ([] call foo);\r\n"
 
 	equal(t, got, want)
 }
 
 func TestParserExpression2(t *testing.T) {
 	got := getCompiled(t, "../../test/parser_expression2.asl")
-	want := "x = true||(3>=4&&5<8);\r\n"
+	want := "x = true || (3 >= 4 && 5 < 8);\r\n"
 
 	equal(t, got, want)
 }
 
 func TestParserFunctionCall(t *testing.T) {
 	got := getCompiled(t, "../../test/parser_func_call.asl")
-	want := "myFunc = {\r\nparams [\"a\",\"b\"];\r\nreturn a>b;\r\n};\r\n[1+3/4, 2-(66*22)/3-((123))] call myFunc;\r\n"
+	want := "myFunc = {\r\n    params [\"a\", \"b\"];\r\n    return a > b;\r\n};\r\n[1 + 3 / 4, 2 - (66 * 22) / 3 - ((123))] call myFunc;\r\n"
 
 	equal(t, got, want)
 }
@@ -125,7 +132,7 @@ func TestParserBinaryBuildinFunctionCall(t *testing.T) {
 
 func TestParserOperator(t *testing.T) {
 	got := getCompiled(t, "../../test/parser_operator.asl")
-	want := "if (x==y&&x!=y&&x<=y&&x>=y&&x<y&&x>y) then {\r\n};\r\n"
+	want := "if (x == y && x != y && x <= y && x >= y && x < y && x > y) then {\r\n};\r\n"
 
 	equal(t, got, want)
 }
@@ -153,21 +160,21 @@ func TestParserExitWith(t *testing.T) {
 
 func TestParserWaitUntil(t *testing.T) {
 	got := getCompiled(t, "../../test/parser_waituntil.asl")
-	want := "waitUntil {x=x+1;x<100};\r\n"
+	want := "waitUntil {x = x + 1; x < 100};\r\n"
 
 	equal(t, got, want)
 }
 
 func TestParserArray(t *testing.T) {
 	got := getCompiled(t, "../../test/parser_array.asl")
-	want := "x = [1,2,3];\r\ny = (x select (1));\r\n"
+	want := "x = [1, 2, 3];\r\ny = (x select (1));\r\n"
 
 	equal(t, got, want)
 }
 
 func TestParserFunctionParams(t *testing.T) {
 	got := getCompiled(t, "../../test/parser_func_params.asl")
-	want := "myFunc = {\r\nparams [[\"a\",1],[\"b\",2]];\r\nreturn a+b;\r\n};\r\n"
+	want := "myFunc = {\r\n    params [[\"a\", 1], [\"b\", 2]];\r\n    return a + b;\r\n};\r\n"
 
 	equal(t, got, want)
 }
@@ -183,18 +190,250 @@ func TestParserPreprocessor(t *testing.T) {
 	types.LoadTypes(types_file)
 
 	got := getCompiled(t, "../../test/tokenizer_preprocessor.asl")
-	want := "\r\n#define HELLO_WORLD \"Hello World!\"\r\nhint HELLO_WORLD;\r\n"
+	want := "#define HELLO_WORLD \"Hello World!\"\r\nhint HELLO_WORLD;\r\n"
 
 	equal(t, got, want)
 }
 
 func TestParserExpressionArray(t *testing.T) {
 	got := getCompiled(t, "../../test/parser_expression_array.asl")
-	want := "x = [1,2,3]-[2,3];\r\n"
+	want := "x = [1, 2, 3] - [2, 3];\r\n"
 
 	equal(t, got, want)
 }
 
+func TestParserMethodCall(t *testing.T) {
+	types.LoadTypes(types_file)
+
+	got := getCompiled(t, "../../test/parser_method_call.asl")
+	want := "someUnit addItem \"NVGoogles\";\r\nsomeUnit setVariable [\"x\", 1];\r\n_hasGoogles = (someUnit hasWeapon \"NVGoogles\");\r\n"
+
+	equal(t, got, want)
+}
+
+func TestParserMethodCallNonBinary(t *testing.T) {
+	types.LoadTypes(types_file)
+
+	tokens, _ := tokenizer.Tokenize([]byte("someUnit.hint(\"text\");"))
+	compiler := parser.Compiler{}
+	_, diagnostics := compiler.Parse(tokens, false)
+
+	if len(diagnostics) != 1 || diagnostics[0].Code != diagnostic.NotBinaryBuildin {
+		t.Error("Method call on unary build in function must fail")
+	}
+}
+
+func TestParserInfixOperator(t *testing.T) {
+	types.LoadTypes(types_file)
+
+	got := getCompiled(t, "../../test/parser_infix_operator.asl")
+	want := "if (x in allUnits && (alive x)) then {\r\n};\r\n_same = a isEqualTo b || a isNotEqualTo [1, 2] && !c;\r\n_equal = _list isEqualTo [1, 2, 3];\r\n_notEqual = [] isNotEqualTo _list || 1 + 2 in _numbers;\r\n_member = [1] isEqualTo (_a in _b);\r\n"
+
+	equal(t, got, want)
+}
+
+func TestParserInterpolation(t *testing.T) {
+	types.LoadTypes(types_file)
+
+	got := getCompiled(t, "../../test/parser_interpolation.asl")
+	want := "hint (format [\"%1 killed %2\", _a, _b]);\r\n_text = (format [\"%1 has %2%3 health {ok}\", (name _unit), _count * 100, \"%\"]);\r\n_nested = (format [\"%1\", _a + \"x\"]);\r\n_quoted = (format [\"say \"\"%1\"\"\", _a + 'x']);\r\n_apostrophe = (format [\"it's %1\", _a]);\r\n_leading = (format [\"'%1\", _a]);\r\n"
+
+	equal(t, got, want)
+}
+
+func TestParserUnterminatedInterpolation(t *testing.T) {
+	for _, code := range []string{"var x = $\"", "hint($\"{_a} x"} {
+		compiler := parser.Compiler{}
+		_, diagnostics := compiler.ParseAST(tokenizer.NewLexer(strings.NewReader(code), ""))
+
+		for _, d := range diagnostics {
+			if d.Code != diagnostic.UnterminatedString && d.Code != diagnostic.UnexpectedEnd && d.Code != diagnostic.ExpectedToken {
+				t.Error("Unterminated interpolated string must only be reported by the lexer, got:", d)
+			}
+		}
+	}
+}
+
+func TestParserString(t *testing.T) {
+	got := getCompiled(t, "../../test/tokenizer_string.asl")
+	want := "a = \"Hello \"\"World\"\"\";\r\nb = 'single ''quoted''';\r\nc = \"\\a3\\ui_f\\data\\map\\markers\\military\\dot_ca.paa\";\r\nd = \"raw \"\"string\"\" // no comment\";\r\ne = \"multi\nline\";\r\nf = {z = \"Hello\";};\r\n"
+
+	equal(t, got, want)
+}
+
+func TestParserNumber(t *testing.T) {
+	got := getCompiled(t, "../../test/tokenizer_number.asl")
+	want := "x = 1.5e-3 + 2E+10 - .5 * 0xFF / $1f - 12;\r\n"
+
+	equal(t, got, want)
+}
+
+func TestParserUTF8(t *testing.T) {
+	types.LoadTypes(types_file)
+
+	got := getCompiled(t, "../../test/tokenizer_utf8.asl")
+	want := "gruss = \"Grüße, Привет!\";\r\nhint (format [\"%1 ёж\", gruss]);\r\n"
+
+	equal(t, got, want)
+}
+
+func TestParserLosslessTree(t *testing.T) {
+	types.LoadTypes(types_file)
+	files, _ := filepath.Glob("../../test/*.asl")
+
+	for _, file := range files {
+		code, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			t.Fatal("Could not read test file: " + file)
+		}
+
+		lexer := tokenizer.NewLexer(bytes.NewReader(code), file)
+		lexer.EnableTrivia()
+		compiler := parser.Compiler{}
+		tree, _ := compiler.ParseTree(lexer)
+
+		if tree.String() != string(code) {
+			t.Error("Source regenerated from syntax tree does not match input: " + file)
+			t.Log(tree.String())
+		}
+	}
+}
+
+func TestParserTreeStructure(t *testing.T) {
+	lexer := tokenizer.NewLexer(strings.NewReader("var x = 1;\nif x < 2 {\n    foo(x);\n}\n"), "")
+	lexer.EnableTrivia()
+	compiler := parser.Compiler{}
+	tree, _ := compiler.ParseTree(lexer)
+
+	if len(tree.Children) != 3 || tree.Children[0].Kind != cst.Var || tree.Children[1].Kind != cst.If || tree.Children[2].Token.Kind != tokenizer.EOF {
+		t.Fatal("Syntax tree must contain var, if and end of file")
+	}
+
+	if got := tree.Children[1].String(); got != "if x < 2 {\n    foo(x);\n}\n" {
+		t.Error("If node does not match its source, was: " + got)
+	}
+}
+
+func TestParserComments(t *testing.T) {
+	code, _ := ioutil.ReadFile("../../test/sqf_comments.asl")
+	lexer := tokenizer.NewLexer(bytes.NewReader(code), "")
+	lexer.EnableTrivia()
+	compiler := parser.Compiler{}
+	file, _ := compiler.ParseAST(lexer)
+
+	if len(file.Comments) != 6 {
+		t.Fatal("Expected 6 comments, but was", len(file.Comments))
+	}
+
+	for _, comment := range file.Comments {
+		if string(code[comment.Token.Start:comment.Token.End]) != comment.Text {
+			t.Error("Comment does not match its source:", comment.Text)
+		}
+	}
+
+	if pos := file.Comments[4].Token; pos.Line != 9 || pos.Column != 5 {
+		t.Error("Unexpected position of comment:", pos.Line, pos.Column)
+	}
+
+	if add, sub := file.Stmts[1].(*ast.Func), file.Stmts[2].(*ast.Func); add.Doc != file.Comments[1] || sub.Doc != nil {
+		t.Error("Only comments starting with /** must be doc comments")
+	}
+}
+
+func TestParserInlineFunction(t *testing.T) {
+	lexer := tokenizer.NewLexer(strings.NewReader("/** doc */\ninline func sq(x) { return x*x; }\nfunc f() {}\nvar inline = 1;"), "")
+	lexer.EnableTrivia()
+	compiler := parser.Compiler{}
+	file, diagnostics := compiler.ParseAST(lexer)
+
+	if len(diagnostics) != 0 || len(file.Stmts) != 3 {
+		t.Fatal("Inline must be a keyword in front of func only, got:", diagnostics)
+	}
+
+	if sq, f := file.Stmts[0].(*ast.Func), file.Stmts[1].(*ast.Func); !sq.Inline || f.Inline || sq.Doc == nil {
+		t.Error("Only functions declared using inline func must be inline")
+	}
+}
+
+func TestParserBuildinSpelling(t *testing.T) {
+	types.LoadTypes(types_file)
+
+	tokens, _ := tokenizer.Tokenize([]byte("var pos = getpos(player);\nplayer.SetVariable(\"pos\", pos);\nhint(\"ok\");"))
+	compiler := parser.Compiler{}
+	got, diagnostics := compiler.Parse(tokens, false)
+	equal(t, got, "pos = (getPos player);player setVariable [\"pos\", pos];hint \"ok\";")
+
+	if len(diagnostics) != 2 || diagnostics[0].Code != diagnostic.BuildinSpelling || diagnostics[0].Severity != diagnostic.Warning {
+		t.Fatal("Build in functions spelled differently than on the wiki must be reported, got:", diagnostics)
+	}
+
+	if len(diagnostics[1].Suggestions) != 1 || diagnostics[1].Suggestions[0] != "setVariable" || diagnostics[1].Range.Start.Line != 2 {
+		t.Error("Spelling on the wiki must be suggested, got:", diagnostics[1])
+	}
+}
+
+func TestParserDiagnostics(t *testing.T) {
+	types.LoadTypes(types_file)
+
+	in, _ := os.Open("../../test/parser_errors.asl")
+	defer in.Close()
+	compiler := parser.Compiler{}
+	got, diagnostics := compiler.ParseLexer(tokenizer.NewLexer(in, "parser_errors.asl"), false)
+	want := []string{diagnostic.ExpectedToken, diagnostic.InvalidInterpolation, diagnostic.BuildinRedeclared, diagnostic.UnexpectedToken}
+
+	if len(diagnostics) != len(want) {
+		t.Fatal("All errors within the file must be reported, got:", diagnostics)
+	}
+
+	for i, d := range diagnostics {
+		if d.Code != want[i] || d.File != "parser_errors.asl" || d.Severity != diagnostic.Error {
+			t.Error("Unexpected diagnostic:", d)
+		}
+	}
+
+	if r := diagnostics[3].Range; r.Start.Line != 5 || r.Start.Column != 9 || r.End.Column != 10 {
+		t.Error("Diagnostic must cover the unexpected token, was:", r)
+	}
+
+	if !strings.HasSuffix(got, "w = 2;") {
+		t.Error("Statements after errors must be parsed, got: " + got)
+	}
+}
+
+func TestParserUnknownFunction(t *testing.T) {
+	types.LoadTypes(types_file)
+
+	tokens, _ := tokenizer.Tokenize([]byte("func sethint(x) { return hnit(x); }\nsetHint(1);"))
+	compiler := parser.Compiler{}
+	_, diagnostics := compiler.Parse(tokens, false)
+
+	if len(diagnostics) != 1 || diagnostics[0].Severity != diagnostic.Warning || diagnostics[0].Code != diagnostic.UnknownFunction {
+		t.Fatal("Call of undeclared function similar to build in function must be reported, got:", diagnostics)
+	}
+
+	if len(diagnostics[0].Suggestions) == 0 || diagnostics[0].Suggestions[0] != "hint" {
+		t.Error("Build in function hint must be suggested")
+	}
+}
+
+func TestParserUnknownFunctionProgram(t *testing.T) {
+	types.LoadTypes(types_file)
+
+	parse := func(code, file string) *ast.File {
+		compiler := parser.Compiler{}
+		tree, _ := compiler.ParseAST(tokenizer.NewLexer(strings.NewReader(code), file))
+		return tree
+	}
+	lib := parse("func sethint(x) { hint(x); }", "lib.asl")
+	main := parse("sethint(1);\nhnit(2);", "main.asl")
+	diagnostics := parser.CheckFunctionNames([]*ast.File{lib, main})
+
+	if len(diagnostics) != 1 || diagnostics[0].File != "main.asl" || diagnostics[0].Range.Start.Line != 2 {
+		t.Error("Functions declared by other files must not be reported, got:", diagnostics)
+	}
+}
+
 // bugfix: unary function parsing (e.g. "format")
 func TestBugfixParserUnaryFunction(t *testing.T) {
 	got := getCompiled(t, "../../test/bugfix_unary_func_format.asl")
@@ -204,17 +443,22 @@ func TestBugfixParserUnaryFunction(t *testing.T) {
 }
 
 func getCompiled(t *testing.T, file string) string {
-	code, err := ioutil.ReadFile(file)
+	in, err := os.Open(file)
 
 	if err != nil {
 		t.Error("Could not read test file: " + file)
 		t.FailNow()
 	}
 
-	tokens := tokenizer.Tokenize(code, false)
+	defer in.Close()
 	compiler := parser.Compiler{}
+	out, diagnostics := compiler.ParseLexer(tokenizer.NewLexer(in, file), true)
+
+	for _, d := range diagnostics {
+		t.Error(d)
+	}
 
-	return compiler.Parse(tokens, true)
+	return out
 }
 
 func equal(t *testing.T, got, want string) {
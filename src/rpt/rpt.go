@@ -0,0 +1,223 @@
+package rpt
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sourcemap"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	mapextension = ".map"
+
+	// characters of the error position used to find its column
+	positionLength = 20
+)
+
+var (
+	// time (and date since Arma 3 2.14) at the beginning of each log line
+	timestamp = regexp.MustCompile(`^\s*(\d{4}/\d{2}/\d{2},\s+)?\d{1,2}:\d{2}:\d{2}(\.\d+)?\s+`)
+
+	// File C:\...\init.sqf [TAG_fnc_init]..., line 12
+	fileLine = regexp.MustCompile(`^(\s*)File (.+?\.sqf)( \[[^\]]*\])?(\.\.\.)?, line (\d+)\s*$`)
+
+	errorPosition = regexp.MustCompile(`^\s*Error position: <(.*)$`)
+	errorStart    = regexp.MustCompile(`^\s*Error in expression <`)
+)
+
+// Script error logged by Arma, referencing a line of an SQF file.
+type ScriptError struct {
+	LogLine  int    // index of the log line naming the file
+	Prefix   string // time stamp of the log line
+	File     string // SQF file as logged
+	Line     int
+	Position string // first line of code at the error position
+}
+
+// Location within an ASL file.
+type Location struct {
+	File   string
+	Line   int
+	Column int
+	Source string // line of ASL code
+}
+
+// Finds ASL files for SQF files named in a log, by their source maps.
+// Since logged paths are absolute paths on the machine running Arma, source maps are looked up
+// by the end of the path within Dir, starting with the longest match.
+type Resolver struct {
+	Dir  string
+	maps map[string]*sourceMap
+}
+
+type sourceMap struct {
+	*sourcemap.SourceMap
+	path      string // of the source map
+	generated []string
+	source    []string
+}
+
+// Returns all script errors within the log.
+func Parse(log string) []ScriptError {
+	errors := make([]ScriptError, 0)
+	position := ""
+
+	for i, line := range strings.Split(log, "\n") {
+		line = strings.TrimRight(line, "\r")
+		prefix := timestamp.FindString(line)
+		message := line[len(prefix):]
+
+		if errorStart.MatchString(message) {
+			position = ""
+		} else if match := errorPosition.FindStringSubmatch(message); match != nil {
+			position = strings.TrimSuffix(match[1], ">")
+		} else if match := fileLine.FindStringSubmatch(message); match != nil {
+			n, _ := strconv.Atoi(match[5])
+			errors = append(errors, ScriptError{
+				LogLine:  i,
+				Prefix:   prefix + match[1],
+				File:     match[2],
+				Line:     n,
+				Position: position})
+			position = ""
+		}
+	}
+
+	return errors
+}
+
+// Returns the log with script errors pointing to ASL files, followed by the line of ASL code.
+// The number of errors rewritten is returned too.
+func Rewrite(log string, r *Resolver) (string, int) {
+	lines := strings.Split(log, "\n")
+	count := 0
+
+	for _, e := range Parse(log) {
+		location, ok := r.Resolve(e)
+
+		if !ok {
+			continue
+		}
+
+		newLine := ""
+
+		if strings.HasSuffix(lines[e.LogLine], "\r") {
+			newLine = "\r"
+		}
+
+		lines[e.LogLine] = e.Prefix + "File " + location.File + ", line " + strconv.Itoa(location.Line) + ", column " + strconv.Itoa(location.Column) +
+			" (" + e.File + ", line " + strconv.Itoa(e.Line) + ")" + newLine
+
+		if location.Source != "" {
+			lines[e.LogLine] += "\n" + e.Prefix + "  " + strings.TrimSpace(location.Source) + newLine
+		}
+
+		count++
+	}
+
+	return strings.Join(lines, "\n"), count
+}
+
+// Returns the ASL location of the script error.
+// False is returned if no source map is found for the SQF file or the line is not mapped.
+func (r *Resolver) Resolve(e ScriptError) (Location, bool) {
+	m := r.find(e.File)
+
+	if m == nil {
+		return Location{}, false
+	}
+
+	mapping, ok := m.Lookup(e.Line, m.column(e.Line, e.Position))
+
+	if !ok {
+		return Location{}, false
+	}
+
+	location := Location{
+		File:   filepath.Join(filepath.Dir(m.path), filepath.FromSlash(m.Source)),
+		Line:   mapping.SourceLine,
+		Column: mapping.SourceColumn}
+
+	if mapping.SourceLine <= len(m.source) {
+		location.Source = m.source[mapping.SourceLine-1]
+	}
+
+	return location, true
+}
+
+// Returns the source map for the logged SQF file, or nil if there is none.
+func (r *Resolver) find(file string) *sourceMap {
+	if r.maps == nil {
+		r.maps = make(map[string]*sourceMap)
+	}
+
+	if m, ok := r.maps[file]; ok {
+		return m
+	}
+
+	r.maps[file] = nil
+	parts := strings.FieldsFunc(file, func(c rune) bool {
+		return c == '\\' || c == '/'
+	})
+
+	for i := range parts {
+		path := filepath.Join(append([]string{r.Dir}, parts[i:]...)...)
+		data, err := ioutil.ReadFile(path + mapextension)
+
+		if err != nil {
+			continue
+		}
+
+		decoded, err := sourcemap.Decode(data)
+
+		if err != nil {
+			continue
+		}
+
+		generated, _ := ioutil.ReadFile(path)
+		source, _ := ioutil.ReadFile(filepath.Join(filepath.Dir(path), filepath.FromSlash(decoded.Source)))
+		r.maps[file] = &sourceMap{decoded, path + mapextension, splitLines(generated), splitLines(source)}
+		break
+	}
+
+	return r.maps[file]
+}
+
+// Returns the column of the error position within given line of generated code.
+// The logged position reaches to the end of the line, so it is searched for at the end first.
+// If it cannot be found, the column is 1.
+func (m *sourceMap) column(line int, position string) int {
+	if line < 1 || line > len(m.generated) || position == "" {
+		return 1
+	}
+
+	code := m.generated[line-1]
+	index := -1
+
+	if strings.HasSuffix(code, position) {
+		index = len(code) - len(position)
+	} else if len(position) > positionLength {
+		index = strings.Index(code, position[:positionLength])
+	} else {
+		index = strings.Index(code, position)
+	}
+
+	if index == -1 {
+		return 1
+	}
+
+	return utf8.RuneCountInString(code[:index]) + 1
+}
+
+func splitLines(data []byte) []string {
+	lines := strings.Split(string(data), "\n")
+
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+
+	return lines
+}
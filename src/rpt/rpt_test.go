@@ -0,0 +1,85 @@
+package rpt_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"rpt"
+	"strings"
+	"testing"
+)
+
+const (
+	rpt_dir  = "../../test/rpt"
+	rpt_file = "../../test/rpt/server.rpt"
+)
+
+func TestRptParse(t *testing.T) {
+	errors := rpt.Parse(readLog(t))
+
+	if len(errors) != 3 {
+		t.Fatal("Expected 3 script errors, but was", len(errors))
+	}
+
+	if errors[0].File != `C:\Users\me\Documents\Arma 3\missions\test.Altis\scripts\init.sqf` || errors[0].Line != 11 || errors[0].Position != "+ 1);" || errors[0].Prefix != " 14:02:31 " {
+		t.Error("Unexpected first script error:", errors[0])
+	}
+
+	if errors[1].Line != 6 || errors[1].Prefix != "2024/05/12, 14:02:32 " || errors[1].Position != `format ["%1 at %2", _i, _pos]);` {
+		t.Error("Unexpected script error with function name:", errors[1])
+	}
+
+	if errors[2].File != `C:\Users\me\Documents\Arma 3\missions\test.Altis\other.sqf` || errors[2].Line != 1 {
+		t.Error("Unexpected script error without source map:", errors[2])
+	}
+}
+
+func TestRptResolve(t *testing.T) {
+	resolver := rpt.Resolver{Dir: rpt_dir}
+	errors := rpt.Parse(readLog(t))
+	want := []rpt.Location{
+		{File: filepath.Join(rpt_dir, "scripts", "init.asl"), Line: 11, Column: 5, Source: "    hint(_units + 1);"},
+		{File: filepath.Join(rpt_dir, "scripts", "init.asl"), Line: 5, Column: 9, Source: "        hint(format(\"%1 at %2\", _i, _pos));"},
+	}
+
+	for i, w := range want {
+		got, ok := resolver.Resolve(errors[i])
+
+		if !ok || got != w {
+			t.Error("Unexpected location, got:", got, "expected:", w)
+		}
+	}
+
+	if _, ok := resolver.Resolve(errors[2]); ok {
+		t.Error("File without source map must not be resolved")
+	}
+}
+
+func TestRptRewrite(t *testing.T) {
+	log := readLog(t)
+	out, n := rpt.Rewrite(log, &rpt.Resolver{Dir: rpt_dir})
+
+	if n != 2 {
+		t.Fatal("Expected 2 rewritten script errors, but was", n)
+	}
+
+	lines := strings.Split(out, "\r\n")
+	want := " 14:02:31 File " + filepath.Join(rpt_dir, "scripts", "init.asl") + `, line 11, column 5 (C:\Users\me\Documents\Arma 3\missions\test.Altis\scripts\init.sqf, line 11)`
+
+	if lines[12] != want || lines[13] != " 14:02:31   hint(_units + 1);" {
+		t.Error("Unexpected rewritten script error, got:", lines[12], lines[13])
+	}
+
+	if len(lines) != len(strings.Split(log, "\r\n"))+2 {
+		t.Error("Log lines must be kept")
+	}
+}
+
+func readLog(t *testing.T) string {
+	log, err := ioutil.ReadFile(rpt_file)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(log)
+}
@@ -0,0 +1,196 @@
+package sourcemap
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+const base64 = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// Maps a position within generated SQF code to a position within ASL source code.
+// Lines and columns start at 1 like token positions, columns are counted in characters.
+type Mapping struct {
+	GeneratedLine   int
+	GeneratedColumn int
+	SourceLine      int
+	SourceColumn    int
+}
+
+// Source map of a generated file with a single source.
+// Mappings must be ordered by generated position.
+type SourceMap struct {
+	File     string // generated file
+	Source   string // ASL file, relative to the source map
+	Mappings []Mapping
+}
+
+// Source map version 3, see https://sourcemaps.info/spec.html
+type sourceMapV3 struct {
+	Version  int      `json:"version"`
+	File     string   `json:"file"`
+	Sources  []string `json:"sources"`
+	Names    []string `json:"names"`
+	Mappings string   `json:"mappings"`
+}
+
+// Returns the source map as JSON in source map version 3 format.
+func (m *SourceMap) Encode() ([]byte, error) {
+	return json.Marshal(sourceMapV3{
+		Version:  3,
+		File:     m.File,
+		Sources:  []string{m.Source},
+		Names:    make([]string, 0),
+		Mappings: encodeMappings(m.Mappings)})
+}
+
+// Reads a source map in version 3 format.
+// Only the first source is taken into account.
+func Decode(data []byte) (*SourceMap, error) {
+	var v3 sourceMapV3
+
+	if err := json.Unmarshal(data, &v3); err != nil {
+		return nil, err
+	}
+
+	if v3.Version != 3 {
+		return nil, errors.New("Unsupported source map version")
+	}
+
+	m := &SourceMap{File: v3.File}
+
+	if len(v3.Sources) > 0 {
+		m.Source = v3.Sources[0]
+	}
+
+	mappings, err := decodeMappings(v3.Mappings)
+	m.Mappings = mappings
+
+	return m, err
+}
+
+// Returns the mapping of the code containing the generated position,
+// which is the last mapping at or before it. False is returned if there is none.
+func (m *SourceMap) Lookup(line, column int) (Mapping, bool) {
+	for i := len(m.Mappings) - 1; i >= 0; i-- {
+		mapping := m.Mappings[i]
+
+		if mapping.GeneratedLine < line || (mapping.GeneratedLine == line && mapping.GeneratedColumn <= column) {
+			return mapping, true
+		}
+	}
+
+	return Mapping{}, false
+}
+
+// Encodes mappings to semicolon separated lines of comma separated segments.
+// Each segment holds the generated column, source index, source line and source column,
+// 0-based and relative to the previous segment as base64 VLQ.
+func encodeMappings(mappings []Mapping) string {
+	out := ""
+	line, column, sourceLine, sourceColumn := 1, 0, 0, 0
+
+	for i, m := range mappings {
+		if m.GeneratedLine > line {
+			out += strings.Repeat(";", m.GeneratedLine-line)
+			line = m.GeneratedLine
+			column = 0
+		} else if i > 0 {
+			out += ","
+		}
+
+		out += encodeVLQ(m.GeneratedColumn-1-column) + encodeVLQ(0) + encodeVLQ(m.SourceLine-1-sourceLine) + encodeVLQ(m.SourceColumn-1-sourceColumn)
+		column, sourceLine, sourceColumn = m.GeneratedColumn-1, m.SourceLine-1, m.SourceColumn-1
+	}
+
+	return out
+}
+
+func decodeMappings(str string) ([]Mapping, error) {
+	mappings := make([]Mapping, 0)
+	sourceLine, sourceColumn := 0, 0
+
+	for i, line := range strings.Split(str, ";") {
+		column := 0
+
+		for _, segment := range strings.Split(line, ",") {
+			if segment == "" {
+				continue
+			}
+
+			values, err := decodeVLQ(segment)
+
+			if err != nil {
+				return mappings, err
+			}
+
+			column += values[0]
+
+			// segments without source position
+			if len(values) < 4 {
+				continue
+			}
+
+			sourceLine += values[2]
+			sourceColumn += values[3]
+			mappings = append(mappings, Mapping{i + 1, column + 1, sourceLine + 1, sourceColumn + 1})
+		}
+	}
+
+	return mappings, nil
+}
+
+// Encodes a number as base64 VLQ. The lowest bit of the first digit is the sign,
+// each digit holds 5 bits and the sixth bit marks that more digits follow.
+func encodeVLQ(n int) string {
+	out := ""
+	n <<= 1
+
+	if n < 0 {
+		n = -n | 1
+	}
+
+	for {
+		digit := n & 31
+		n >>= 5
+
+		if n > 0 {
+			digit |= 32
+		}
+
+		out += base64[digit : digit+1]
+
+		if n == 0 {
+			return out
+		}
+	}
+}
+
+// Decodes all base64 VLQ numbers within the segment.
+func decodeVLQ(segment string) ([]int, error) {
+	values := make([]int, 0)
+	n, shift := 0, uint(0)
+
+	for i := 0; i < len(segment); i++ {
+		digit := strings.IndexByte(base64, segment[i])
+
+		if digit == -1 {
+			return values, errors.New("Invalid character in source map mappings: " + segment[i:i+1])
+		}
+
+		n += (digit & 31) << shift
+		shift += 5
+
+		if digit&32 == 0 {
+			if n&1 == 1 {
+				values = append(values, -(n >> 1))
+			} else {
+				values = append(values, n>>1)
+			}
+
+			n, shift = 0, 0
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,81 @@
+package sourcemap_test
+
+import (
+	"sourcemap"
+	"testing"
+)
+
+func mappings(values ...[4]int) []sourcemap.Mapping {
+	mappings := make([]sourcemap.Mapping, 0, len(values))
+
+	for _, v := range values {
+		mappings = append(mappings, sourcemap.Mapping{GeneratedLine: v[0], GeneratedColumn: v[1], SourceLine: v[2], SourceColumn: v[3]})
+	}
+
+	return mappings
+}
+
+func TestSourceMapEncode(t *testing.T) {
+	m := sourcemap.SourceMap{File: "test.sqf", Source: "test.asl", Mappings: mappings(
+		[4]int{1, 1, 1, 1},
+		[4]int{1, 16, 2, 5},
+		[4]int{2, 1, 3, 9},
+		[4]int{4, 1000, 1, 1})}
+	got, err := m.Encode()
+	want := `{"version":3,"file":"test.sqf","sources":["test.asl"],"names":[],"mappings":"AAAA,eACI;AACI;;u+BAFR"}`
+
+	if err != nil || string(got) != want {
+		t.Error("Unexpected source map, got: " + string(got))
+	}
+}
+
+func TestSourceMapDecode(t *testing.T) {
+	m := sourcemap.SourceMap{File: "test.sqf", Source: "../test.asl", Mappings: mappings(
+		[4]int{1, 1, 1, 1},
+		[4]int{1, 40, 3, 5},
+		[4]int{1, 2345, 10, 2},
+		[4]int{5, 3, 7, 12})}
+	data, _ := m.Encode()
+	decoded, err := sourcemap.Decode(data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.File != m.File || decoded.Source != m.Source || len(decoded.Mappings) != len(m.Mappings) {
+		t.Fatal("Decoded source map does not match encoded one")
+	}
+
+	for i := range m.Mappings {
+		if decoded.Mappings[i] != m.Mappings[i] {
+			t.Error("Mapping does not match:", decoded.Mappings[i], m.Mappings[i])
+		}
+	}
+
+	if _, err := sourcemap.Decode([]byte(`{"version":3,"mappings":"A!"}`)); err == nil {
+		t.Error("Invalid mappings must fail")
+	}
+}
+
+func TestSourceMapLookup(t *testing.T) {
+	m := sourcemap.SourceMap{Mappings: mappings(
+		[4]int{1, 1, 1, 1},
+		[4]int{1, 20, 2, 5},
+		[4]int{3, 5, 4, 1})}
+
+	if got, ok := m.Lookup(1, 25); !ok || got.SourceLine != 2 {
+		t.Error("Position must be mapped to preceding statement")
+	}
+
+	if got, ok := m.Lookup(3, 1); !ok || got.SourceLine != 2 {
+		t.Error("Position must be mapped to statement of previous line")
+	}
+
+	if got, ok := m.Lookup(3, 5); !ok || got.SourceLine != 4 {
+		t.Error("Position must be mapped to statement starting at it")
+	}
+
+	if _, ok := (&sourcemap.SourceMap{}).Lookup(1, 1); ok {
+		t.Error("Empty source map must not map anything")
+	}
+}
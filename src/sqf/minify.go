@@ -0,0 +1,405 @@
+package sqf
+
+import (
+	"ast"
+	"regexp"
+	"sort"
+	"sourcemap"
+	"strconv"
+	"strings"
+	"tokenizer"
+)
+
+// Precedence of SQF expressions, from lowest to highest binding.
+const (
+	precAssign = iota
+	precOr
+	precAnd
+	precComparison
+	precBinary // binary commands like select, call and isEqualTo
+	precElse
+	precArith
+	precFactor
+	precPower
+	precHash
+	precUnary
+	precNular
+)
+
+var (
+	// variables set by the game, which are never renamed nor used as short names
+	magicVariables = []string{"_x", "_y", "_this", "_foreachindex", "_exception", "_time", "_thisscript", "_thisfsm",
+		"_thisevent", "_thiseventhandler", "_thisscriptedeventhandler", "_thisargs", "_thisid",
+		"_fnc_scriptname", "_fnc_scriptnameparent"}
+
+	// private variable names within strings, like isNil "_var"
+	privateVariable = regexp.MustCompile(`_[a-zA-Z0-9_]+`)
+)
+
+// Returns the precedence of an expression as written in SQF.
+func precedence(expr ast.Expr) int {
+	switch n := expr.(type) {
+	case *ast.Paren:
+		return precedence(n.X)
+	case *ast.Unary, *ast.Interpolation:
+		return precUnary
+	case *ast.Index, *ast.Call:
+		return precBinary
+	case *ast.BuiltinCall:
+		if n.Type == ast.NullCall {
+			return precNular
+		} else if n.Type == ast.UnaryCall {
+			return precUnary
+		}
+
+		return precBinary
+	case *ast.Binary:
+		switch binaryOperator(n) {
+		case "=":
+			return precAssign
+		case "||":
+			return precOr
+		case "&&":
+			return precAnd
+		case "==", "!=", "<", ">", "<=", ">=":
+			return precComparison
+		case "+", "-":
+			return precArith
+		case "*", "/", "%":
+			return precFactor
+		}
+
+		return precBinary
+	}
+
+	return precNular
+}
+
+// Returns the SQF code for an operand, enclosed in parentheses if it binds weaker than given precedence level.
+func (e *Emitter) operand(expr ast.Expr, level int) string {
+	if e.precedence(expr) < level {
+		return "(" + e.expr(expr) + ")"
+	}
+
+	return e.expr(expr)
+}
+
+// Returns the precedence of an expression as written by the emitter.
+// Unless minifying, parentheses, calls, index and format expressions are written enclosed in parentheses.
+func (e *Emitter) precedence(expr ast.Expr) int {
+	if e.options.Minify {
+		return precedence(expr)
+	}
+
+	switch n := expr.(type) {
+	case *ast.Paren, *ast.Index, *ast.Call, *ast.Interpolation:
+		return precNular
+	case *ast.BuiltinCall:
+		if n.Type != ast.NullCall {
+			return precNular
+		}
+	}
+
+	return precedence(expr)
+}
+
+// Returns the name of a variable, which is shortened when minifying private variables of a function.
+func (e *Emitter) name(ident *ast.Ident) string {
+	if short, ok := e.locals[strings.ToLower(ident.Name)]; ok {
+		return short
+	}
+
+	return ident.Name
+}
+
+// Returns the shortest form of a number or string literal.
+func shortLiteral(literal *ast.Literal) string {
+	switch literal.Kind {
+	case tokenizer.String:
+		return shortString(tokenizer.Unquote(literal.Value))
+	case tokenizer.Number:
+		return shortNumber(literal.Value)
+	}
+
+	return literal.Value
+}
+
+// Quotes the string using the quote character which requires less escaping.
+func shortString(str string) string {
+	if strings.Count(str, "'") < strings.Count(str, "\"") {
+		return "'" + strings.Replace(str, "'", "''", -1) + "'"
+	}
+
+	return tokenizer.Quote(str)
+}
+
+// Returns the shortest form of a number, like .5 for 0.50, 1e6 for 1000000 or 255 for 0xFF.
+func shortNumber(number string) string {
+	var value float64
+	lower := strings.ToLower(number)
+
+	if strings.HasPrefix(lower, "$") || strings.HasPrefix(lower, "0x") {
+		n, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(lower, "$"), "0x"), 16, 64)
+
+		if err != nil {
+			return number
+		}
+
+		value = float64(n)
+	} else if n, err := strconv.ParseFloat(number, 64); err == nil {
+		value = n
+	} else {
+		return number
+	}
+
+	shortest := number
+
+	for _, format := range []byte{'f', 'e'} {
+		str := strconv.FormatFloat(value, format, -1, 64)
+		str = strings.Replace(str, "e+", "e", 1)
+		str = strings.Replace(str, "e0", "e", 1)
+		str = strings.Replace(str, "e-0", "e-", 1)
+
+		if strings.HasPrefix(str, "0.") {
+			str = str[1:]
+		}
+
+		if len(str) < len(shortest) {
+			shortest = str
+		}
+	}
+
+	return shortest
+}
+
+// Returns short names for the private variables declared within functions: parameters, variables,
+// foreach elements and for loop variables. Since SQF variable names are case insensitive, they are mapped
+// in lower case. Each name is renamed the same way throughout the code, so that variables shared with
+// called functions keep working, and the most used variables get the shortest names.
+// Variables which are used but not declared by a function, used outside of functions or named within
+// strings, like isNil "_var", keep their names, as well as variables set by the game.
+func privateNames(node ast.Node) map[string]string {
+	kept := make(map[string]bool)
+	uses := make(map[string]int)
+	names := make([]string, 0)
+	listed := make(map[string]bool)
+
+	for _, name := range magicVariables {
+		kept[name] = true
+	}
+
+	var scan func(node ast.Node, declared map[string]bool)
+	scan = func(node ast.Node, declared map[string]bool) {
+		ast.Inspect(node, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Func:
+				if n != node {
+					scan(n, declaredVariables(n))
+					return false
+				}
+
+				for _, name := range declarationOrder(n) {
+					if !listed[name] {
+						names = append(names, name)
+						listed[name] = true
+					}
+				}
+			case *ast.Ident:
+				name := strings.ToLower(n.Name)
+				uses[name]++
+
+				if strings.HasPrefix(name, "_") && !declared[name] {
+					kept[name] = true
+				}
+			case *ast.Literal:
+				if n.Kind == tokenizer.String {
+					for _, name := range privateVariable.FindAllString(n.Value, -1) {
+						kept[strings.ToLower(name)] = true
+					}
+				}
+			}
+
+			return true
+		})
+	}
+
+	if f, ok := node.(*ast.Func); ok {
+		scan(f, declaredVariables(f))
+	} else {
+		scan(node, nil)
+	}
+
+	renamed := make([]string, 0, len(names))
+
+	for _, name := range names {
+		if !kept[name] {
+			renamed = append(renamed, name)
+		}
+	}
+
+	sort.SliceStable(renamed, func(i, j int) bool {
+		return uses[renamed[i]] > uses[renamed[j]]
+	})
+
+	locals := make(map[string]string)
+	next := 0
+
+	for _, name := range renamed {
+		for kept[shortName(next)] {
+			next++
+		}
+
+		locals[name] = shortName(next)
+		next++
+	}
+
+	return locals
+}
+
+// Returns the private variables declared within the function in lower case.
+func declaredVariables(f *ast.Func) map[string]bool {
+	declared := make(map[string]bool)
+
+	for _, name := range declarationOrder(f) {
+		declared[name] = true
+	}
+
+	return declared
+}
+
+// Returns the private variables declared within the function in lower case, in order of declaration.
+func declarationOrder(f *ast.Func) []string {
+	names := make([]string, 0)
+	declare := func(name string) {
+		name = strings.ToLower(name)
+
+		if !strings.HasPrefix(name, "_") {
+			return
+		}
+
+		for _, declared := range names {
+			if declared == name {
+				return
+			}
+		}
+
+		names = append(names, name)
+	}
+
+	for _, param := range f.Params {
+		declare(param.Name.Name)
+	}
+
+	ast.Inspect(f.Body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Var:
+			declare(n.Name.Name)
+		case *ast.Foreach:
+			declare(n.Elem.Name)
+		case *ast.For:
+			if init, ok := n.Init.(*ast.Binary); ok && n.Var {
+				if ident, ok := init.X.(*ast.Ident); ok {
+					declare(ident.Name)
+				}
+			}
+		}
+
+		return true
+	})
+
+	return names
+}
+
+// Returns the n-th short variable name: _a to _z, followed by _aa, _ab and so on.
+func shortName(n int) string {
+	name := ""
+
+	for n++; n > 0; n = (n - 1) / 26 {
+		name = string(rune('a'+(n-1)%26)) + name
+	}
+
+	return "_" + name
+}
+
+// Removes whitespace not required to separate tokens, except within strings, comments and preprocessor directives.
+// Line breaks are kept, since they only appear around preprocessor directives and comments in compact code.
+// The generated columns of the mappings, which must be in output order, are moved to the minified code.
+func minifyWhitespace(code string, mappings []sourcemap.Mapping) string {
+	out := make([]byte, 0, len(code))
+	var quote byte
+	lineStart := true
+	verbatim := 0 // end of the comment or preprocessor directive
+	line, column, minifiedColumn, next := 1, 1, 1, 0
+
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+
+		for next < len(mappings) && (mappings[next].GeneratedLine < line ||
+			mappings[next].GeneratedLine == line && mappings[next].GeneratedColumn <= column) {
+			mappings[next].GeneratedColumn = minifiedColumn
+			next++
+		}
+
+		// columns count characters rather than bytes
+		if c&0xC0 != 0x80 {
+			column++
+		}
+
+		switch {
+		case i < verbatim:
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case lineStart && c == '#', strings.HasPrefix(code[i:], "//"):
+			verbatim = lineEnd(code, i)
+		case strings.HasPrefix(code[i:], "/*"):
+			verbatim = len(code)
+
+			if end := strings.Index(code[i+2:], "*/"); end != -1 {
+				verbatim = i + 2 + end + 2
+			}
+		case c == ' ' || c == '\t':
+			if len(out) > 0 && i+1 < len(code) && separate(out[len(out)-1], code[i+1]) {
+				out = append(out, ' ')
+				minifiedColumn++
+			}
+
+			continue
+		case c == '"' || c == '\'':
+			quote = c
+		}
+
+		if c == '\n' {
+			line++
+			column, minifiedColumn = 1, 1
+		} else if c&0xC0 != 0x80 {
+			minifiedColumn++
+		}
+
+		lineStart = c == '\n'
+		out = append(out, c)
+	}
+
+	return string(out)
+}
+
+// Returns the index of the line break ending the line at index i, or the length of code.
+func lineEnd(code string, i int) int {
+	if end := strings.IndexAny(code[i:], "\r\n"); end != -1 {
+		return i + end
+	}
+
+	return len(code)
+}
+
+// Returns true if the characters must be separated by a space, like the letters of
+// a name and a named operator or the signs of a subtraction of a negative number.
+func separate(left, right byte) bool {
+	return isWordCharacter(left) && isWordCharacter(right) ||
+		(left == '+' || left == '-') && (right == '+' || right == '-')
+}
+
+func isWordCharacter(c byte) bool {
+	return isLetter(c) || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '$' || c >= 0x80
+}
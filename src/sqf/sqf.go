@@ -0,0 +1,726 @@
+package sqf
+
+import (
+	"ast"
+	"math"
+	"sourcemap"
+	"strconv"
+	"strings"
+	"tokenizer"
+	"unicode/utf8"
+)
+
+const (
+	CRLF = "\r\n"
+	LF   = "\n"
+
+	// spaces per indentation level if not set
+	default_indent = 4
+)
+
+// Controls how SQF code is written.
+// The zero value writes compact code, using CRLF line breaks around preprocessor directives only.
+type Options struct {
+	Pretty        bool   // statements on lines of their own, indented and with spaces around operators
+	PreserveLines bool   // statements on the line of the statement in source code, see EmitLines
+	Indent        int    // spaces per indentation level, or the width of a tab to calculate line width
+	Tabs          bool   // indent using tabs instead of spaces
+	NewLine       string // CRLF or LF
+	MaxWidth      int    // line width at which arrays and argument lists are wrapped, 0 to disable
+	Minify        bool   // shortest code, renaming private variables of functions, see EmitOptions
+	KeepComments  bool   // write comments in front of the statements following them, see EmitOptions
+	Header        string // written as comment in front of the code, like the source of generated code
+}
+
+// Writes SQF code for an abstract syntax tree.
+type Emitter struct {
+	out        string
+	options    Options
+	file       string // used in #line directives
+	level      int    // indentation level of statements
+	prefix     int    // width of the code in front of the expression written on its line
+	line       int    // position of next character written
+	column     int
+	lineOffset int // difference of line numbers set by #line directives to line
+	mappings   []sourcemap.Mapping
+	locals     map[string]string // short names of private variables when minifying
+	comments   []*ast.Comment    // comments not written yet
+	sourceLine int               // line in source code of the statement written last
+}
+
+// Returns the SQF code for given node.
+// Pretty printing writes statements on lines of their own, indented by four spaces.
+func Emit(node ast.Node, prettyPrinting bool) string {
+	out, _ := EmitMap(node, prettyPrinting)
+	return out
+}
+
+// Returns the SQF code for given node like Emit, together with mappings
+// from the beginning of each statement within the output to the statement in source code.
+func EmitMap(node ast.Node, prettyPrinting bool) (string, []sourcemap.Mapping) {
+	return EmitOptions(node, Options{Pretty: prettyPrinting})
+}
+
+// Returns the SQF code for given node like EmitMap, but each statement starts at the line number
+// of the statement in source code. Lines are padded and multi line statements compacted to match.
+// If a statement cannot start at its line, because output went past it, a #line directive is emitted in front.
+func EmitLines(node ast.Node) (string, []sourcemap.Mapping) {
+	return EmitOptions(node, Options{PreserveLines: true})
+}
+
+// Returns the SQF code for given node written as configured, together with mappings like EmitMap.
+// Minifying writes compact code without unnecessary parentheses and whitespace, using the shortest
+// form of literals. Private variables declared within functions are renamed to short names,
+// unless they are used outside of the function declaring them or named within strings.
+// Doc comments of functions are always written in front of them. If comments are kept,
+// all comments of a file are written in front of the statement following them,
+// or behind the statement on the same line in source code.
+func EmitOptions(node ast.Node, options Options) (string, []sourcemap.Mapping) {
+	if options.Indent <= 0 {
+		options.Indent = default_indent
+	}
+
+	if options.NewLine == "" {
+		options.NewLine = CRLF
+	}
+
+	if options.PreserveLines || options.Minify {
+		options.Pretty = false
+	}
+
+	if options.Minify {
+		options.PreserveLines = false
+	}
+
+	e := Emitter{options: options, line: 1, column: 1}
+
+	if options.Minify {
+		e.locals = privateNames(node)
+	}
+
+	e.comments = comments(node, options.KeepComments)
+
+	if options.Header != "" {
+		e.emitHeader(options.Header)
+	}
+
+	if file, ok := node.(*ast.File); ok && options.PreserveLines {
+		e.file = file.Name
+	}
+
+	switch n := node.(type) {
+	case *ast.File:
+		e.emitStmts(n.Stmts)
+		e.emitComments(math.MaxInt)
+	case *ast.Block:
+		e.emitStmts(n.Stmts)
+	case ast.Stmt:
+		e.emitStmt(n)
+	case ast.Expr:
+		e.out = e.expr(n)
+	}
+
+	if options.Minify {
+		return minifyWhitespace(e.out, e.mappings), e.mappings
+	}
+
+	return e.out, e.mappings
+}
+
+func (e *Emitter) emitStmts(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		e.emitStmt(stmt)
+	}
+}
+
+// Emits the statements of a block indented by one level, followed by the comments at its end.
+func (e *Emitter) emitBlock(block *ast.Block) {
+	e.level++
+	e.emitStmts(block.Stmts)
+	e.emitComments(block.End.Start)
+	e.level--
+}
+
+func (e *Emitter) emitStmt(stmt ast.Stmt) {
+	e.emitComments(stmt.Pos().Start)
+	e.prefix = 0
+
+	if _, ok := stmt.(*ast.Preprocessor); !ok {
+		e.alignLine(stmt, false)
+		e.appendIndent()
+		e.mark(stmt)
+	}
+
+	switch n := stmt.(type) {
+	case *ast.Block:
+		e.emitStmts(n.Stmts)
+	case *ast.Preprocessor:
+		// we definitely want a new line before and after
+		if e.options.PreserveLines {
+			e.alignLine(n, true)
+		} else if e.column > 1 {
+			e.appendOut(e.options.NewLine, false)
+		}
+
+		e.mark(n)
+		e.appendOut(n.Text+e.options.NewLine, false)
+	case *ast.Var:
+		if n.Value != nil {
+			e.appendOut(e.exprAt(e.name(n.Name)+" = ", n.Value)+";", true)
+		} else {
+			e.appendOut(e.name(n.Name)+";", true)
+		}
+	case *ast.Assign:
+		e.appendOut(e.exprAt(e.name(n.Name)+" = ", n.Value)+";", true)
+	case *ast.If:
+		e.appendOut(e.exprAt("if (", n.Cond)+") then {", true)
+		e.emitBlock(n.Then)
+
+		if n.Else != nil {
+			e.appendOut("} else {", true)
+			e.emitBlock(n.Else)
+		}
+
+		e.appendOut("};", true)
+	case *ast.While:
+		e.appendOut(e.exprAt("while {", n.Cond)+"} do {", true)
+		e.emitBlock(n.Body)
+		e.appendOut("};", true)
+	case *ast.Switch:
+		e.appendOut(e.exprAt("switch (", n.Expr)+") do {", true)
+		e.level++
+
+		for _, c := range n.Cases {
+			e.emitCase(c)
+		}
+
+		e.level--
+		e.appendOut("};", true)
+	case *ast.For:
+		e.appendOut("for [{"+e.expr(n.Init)+"}, {"+e.expr(n.Cond)+"}, {"+e.expr(n.Post)+"}] do {", true)
+		e.emitBlock(n.Body)
+		e.appendOut("};", true)
+	case *ast.Foreach:
+		e.appendOut("{", true)
+		e.level++
+		e.appendOut(e.name(n.Elem)+" = _x;", true)
+		e.emitStmts(n.Body.Stmts)
+		e.emitComments(n.Body.End.Start)
+		e.level--
+		e.appendOut(e.exprAt("} forEach (", n.Expr)+");", true)
+	case *ast.Func:
+		e.emitFunc(n)
+	case *ast.Return:
+		e.appendOut(e.exprAt("return ", n.Value)+";", true)
+	case *ast.Try:
+		e.appendOut("try {", true)
+		e.emitBlock(n.Body)
+		e.appendOut("} catch {", true)
+		e.emitBlock(n.Catch)
+		e.appendOut("};", true)
+	case *ast.ExitWith:
+		e.appendOut("if (true) exitWith {", true)
+		e.emitBlock(n.Body)
+		e.appendOut("};", true)
+	case *ast.WaitUntil:
+		separator := ";"
+
+		if e.options.Pretty {
+			separator = "; "
+		}
+
+		e.appendOut("waitUntil {"+e.exprList(n.Exprs, separator)+"};", true)
+	case *ast.ExprStmt:
+		e.appendOut(e.statementExpr(n.X)+";", true)
+	}
+}
+
+func (e *Emitter) emitCase(c *ast.Case) {
+	e.emitComments(c.Pos().Start)
+	e.prefix = 0
+	e.alignLine(c, false)
+	e.appendIndent()
+	e.mark(c)
+	label := "default:"
+
+	if c.Expr != nil {
+		label = e.exprAt("case ", c.Expr) + ":"
+	}
+
+	if c.Body == nil {
+		e.appendOut(label, true)
+		return
+	}
+
+	// the code of a case starts on the line of the case when pretty printing
+	if e.options.Pretty {
+		e.appendOut(label+" {", true)
+	} else {
+		e.appendOut(label+"{", false)
+	}
+
+	e.emitBlock(c.Body)
+	e.appendOut("};", true)
+}
+
+func (e *Emitter) emitFunc(f *ast.Func) {
+	e.appendOut(f.Name.Name+" = {", true)
+	e.level++
+
+	if len(f.Params) > 0 {
+		params := make([]string, 0, len(f.Params))
+
+		for _, param := range f.Params {
+			if param.Default != nil {
+				params = append(params, "["+tokenizer.Quote(e.name(param.Name))+e.separator()+e.expr(param.Default)+"]")
+			} else {
+				params = append(params, tokenizer.Quote(e.name(param.Name)))
+			}
+		}
+
+		e.prefix = len("params ")
+		e.appendOut("params "+e.wrap("[", params, "]")+";", true)
+	}
+
+	e.emitStmts(f.Body.Stmts)
+	e.emitComments(f.Body.End.Start)
+	e.level--
+	e.appendOut("};", true)
+}
+
+// Writes the header comment on the first line.
+// When preserving lines, the code of the first line follows it on the same line.
+func (e *Emitter) emitHeader(header string) {
+	e.appendOut("/* "+strings.Replace(header, "*/", "* /", -1)+" */", false)
+
+	if !e.options.PreserveLines {
+		e.appendOut(e.options.NewLine, false)
+	}
+}
+
+// Writes the comments in front of given offset in source code.
+// Comments on the line of the statement written last are written behind it.
+// Line comments and doc comments end their line, doc comments also start on a line of their own.
+func (e *Emitter) emitComments(end int) {
+	for len(e.comments) > 0 && e.comments[0].Token.Start < end {
+		comment := e.comments[0]
+		e.comments = e.comments[1:]
+		lineComment := strings.HasPrefix(comment.Text, "//")
+
+		if comment.Token.Line == e.sourceLine && !comment.Doc() {
+			// the new line behind the statement is written behind the comment instead
+			if e.options.Pretty && strings.HasSuffix(e.out, e.options.NewLine) {
+				e.out = e.out[:len(e.out)-len(e.options.NewLine)]
+				e.line--
+				e.column = utf8.RuneCountInString(e.out[strings.LastIndexByte(e.out, '\n')+1:]) + 1
+				e.appendOut(" "+comment.Text+e.options.NewLine, false)
+			} else {
+				e.appendOut(comment.Text, false)
+
+				if lineComment {
+					e.appendOut(e.options.NewLine, false)
+				}
+			}
+
+			continue
+		}
+
+		e.alignLine(comment, false)
+
+		if comment.Doc() && e.column > 1 {
+			e.appendOut(e.options.NewLine, false)
+		}
+
+		e.appendIndent()
+		e.appendOut(comment.Text, true)
+
+		if !e.options.Pretty && (lineComment || comment.Doc()) {
+			e.appendOut(e.options.NewLine, false)
+		}
+	}
+}
+
+// Returns the SQF code for an expression written behind given code on the same line.
+func (e *Emitter) exprAt(prefix string, expr ast.Expr) string {
+	e.prefix = utf8.RuneCountInString(prefix)
+	return prefix + e.expr(expr)
+}
+
+// Returns the SQF code for an expression.
+func (e *Emitter) expr(expr ast.Expr) string {
+	switch n := expr.(type) {
+	case *ast.Ident:
+		return e.name(n)
+	case *ast.Literal:
+		if e.options.Minify {
+			return shortLiteral(n)
+		}
+
+		// raw strings are converted to SQF strings
+		if n.Kind == tokenizer.String && n.Value[0] == '`' {
+			return tokenizer.Quote(tokenizer.Unquote(n.Value))
+		}
+
+		return n.Value
+	case *ast.Array:
+		return e.list("[", n.Elems, "]")
+	case *ast.Paren:
+		// operands are enclosed as required when minifying
+		if e.options.Minify {
+			return e.expr(n.X)
+		}
+
+		return "(" + e.expr(n.X) + ")"
+	case *ast.Unary:
+		if n.Op == "not" {
+			return "!" + e.operand(n.X, precUnary)
+		}
+
+		return n.Op + e.operand(n.X, precUnary)
+	case *ast.Binary:
+		return e.binary(n)
+	case *ast.Index:
+		if e.options.Minify {
+			return e.operand(n.X, precBinary) + " select " + e.operand(n.Index, precBinary+1)
+		}
+
+		return "(" + e.expr(n.X) + " select (" + e.expr(n.Index) + "))"
+	case *ast.Call, *ast.BuiltinCall:
+		if e.options.Minify {
+			return e.call(n, false)
+		}
+
+		return "(" + e.call(n, false) + ")"
+	case *ast.Code:
+		// inline code is always written on a single line
+		code := Emitter{options: e.options, line: 1, column: 1, locals: e.locals}
+		code.options.Pretty = false
+		code.options.PreserveLines = false
+		code.emitStmts(n.Body.Stmts)
+		return "{" + code.out + "}"
+	case *ast.Interpolation:
+		args := []ast.Expr{&ast.Literal{Kind: tokenizer.String, Value: "\"" + n.Format + "\""}}
+
+		if e.options.Minify {
+			return "format " + e.args(append(args, n.Args...), 0)
+		}
+
+		return "(format " + e.args(append(args, n.Args...), 0) + ")"
+	}
+
+	return ""
+}
+
+// Returns the SQF code for an expression used as statement,
+// function calls are not enclosed in parentheses.
+func (e *Emitter) statementExpr(expr ast.Expr) string {
+	switch expr.(type) {
+	case *ast.Call, *ast.BuiltinCall:
+		return e.call(expr, true)
+	}
+
+	return e.expr(expr)
+}
+
+func (e *Emitter) call(expr ast.Expr, statement bool) string {
+	switch n := expr.(type) {
+	case *ast.Call:
+		return e.args(n.Args, utf8.RuneCountInString(" call "+n.Name.Name)) + " call " + e.name(n.Name)
+	case *ast.BuiltinCall:
+		if n.Type == ast.NullCall {
+			return n.Name.Name
+		} else if n.Type == ast.UnaryCall {
+			e.prefix += utf8.RuneCountInString(n.Name.Name) + 1
+
+			if len(n.Right) == 1 {
+				return n.Name.Name + " " + e.operand(n.Right[0], precUnary)
+			}
+
+			return n.Name.Name + " " + e.args(n.Right, 0)
+		}
+
+		left := ""
+
+		if n.Method && statement && !e.options.Minify {
+			left = e.statementExpr(n.Left[0]) + " "
+		} else if len(n.Left) > 0 {
+			left = e.params(n.Left, precBinary) + " "
+		}
+
+		e.prefix += utf8.RuneCountInString(left+n.Name.Name) + 1
+		return left + n.Name.Name + " " + e.params(n.Right, precBinary+1)
+	}
+
+	return ""
+}
+
+// Returns parameters of binary build in functions, multiple parameters are passed as array.
+// A single parameter is enclosed in parentheses if it binds weaker than given precedence.
+func (e *Emitter) params(params []ast.Expr, precedence int) string {
+	if len(params) > 1 {
+		return e.args(params, 0)
+	} else if len(params) == 1 {
+		return e.operand(params[0], precedence)
+	}
+
+	return ""
+}
+
+// Returns an array of arguments passed to a function, followed by code of given width on the same line.
+// Unlike array literals, arguments are always separated by a comma and space.
+func (e *Emitter) args(args []ast.Expr, trailing int) string {
+	return e.listSeparated("[", args, ", ", "]", trailing)
+}
+
+// Returns the SQF code for a binary operation. Operators are left associative.
+func (e *Emitter) binary(n *ast.Binary) string {
+	level := precedence(n)
+	left, right := e.operand(n.X, level), e.operand(n.Y, level+1)
+	operator := binaryOperator(n)
+
+	// named operators must be separated from their operands
+	if e.options.Pretty || isLetter(operator[0]) {
+		operator = " " + operator + " "
+	}
+
+	return left + operator + right
+}
+
+func (e *Emitter) exprList(exprs []ast.Expr, separator string) string {
+	output := make([]string, 0, len(exprs))
+
+	for _, expr := range exprs {
+		output = append(output, e.expr(expr))
+	}
+
+	return strings.Join(output, separator)
+}
+
+// Returns the expressions as array literal.
+func (e *Emitter) list(open string, exprs []ast.Expr, close string) string {
+	return e.listSeparated(open, exprs, e.separator(), close, 0)
+}
+
+// Returns the expressions separated and enclosed by open and close.
+// Lists exceeding the maximum line width, together with the code of trailing width behind them,
+// are written with one expression per line.
+func (e *Emitter) listSeparated(open string, exprs []ast.Expr, separator, close string, trailing int) string {
+	prefix := e.prefix
+	flat := open + e.exprList(exprs, separator) + close
+
+	if len(exprs) == 0 || !e.exceedsWidth(prefix+trailing, flat) {
+		return flat
+	}
+
+	// expressions start on lines of their own
+	e.level++
+	output := make([]string, 0, len(exprs))
+
+	for _, expr := range exprs {
+		e.prefix = 0
+		output = append(output, e.expr(expr))
+	}
+
+	e.level--
+	e.prefix = prefix
+	return e.wrapLines(open, output, close)
+}
+
+// Returns the elements separated and enclosed by open and close,
+// with one element per line if they exceed the maximum line width.
+func (e *Emitter) wrap(open string, elems []string, close string) string {
+	flat := open + strings.Join(elems, e.separator()) + close
+
+	if len(elems) == 0 || !e.exceedsWidth(e.prefix, flat) {
+		return flat
+	}
+
+	return e.wrapLines(open, elems, close)
+}
+
+func (e *Emitter) wrapLines(open string, elems []string, close string) string {
+	indent := e.options.NewLine + e.indentation(e.level+1)
+	return open + indent + strings.Join(elems, ","+indent) + e.options.NewLine + e.indentation(e.level) + close
+}
+
+// Returns true if the code written behind prefix, on a line at current indentation level, is wider than allowed.
+// Code containing line breaks, like wrapped lists, is always too wide.
+func (e *Emitter) exceedsWidth(prefix int, code string) bool {
+	if !e.options.Pretty || e.options.MaxWidth <= 0 {
+		return false
+	}
+
+	if strings.ContainsAny(code, "\r\n") {
+		return true
+	}
+
+	return e.level*e.options.Indent+prefix+utf8.RuneCountInString(code) > e.options.MaxWidth
+}
+
+// Returns the separator of array elements.
+func (e *Emitter) separator() string {
+	if e.options.Pretty {
+		return ", "
+	}
+
+	return ","
+}
+
+// Returns the whitespace in front of a line at given indentation level.
+func (e *Emitter) indentation(level int) string {
+	if e.options.Tabs {
+		return strings.Repeat("\t", level)
+	}
+
+	return strings.Repeat(" ", level*e.options.Indent)
+}
+
+// Moves the output to the line of the node in source code when preserving lines, by adding new lines
+// or a #line directive. If lineStart is true, the node must start at the beginning of a line.
+func (e *Emitter) alignLine(node ast.Node, lineStart bool) {
+	line := node.Pos().Line
+
+	if !e.options.PreserveLines || line == 0 {
+		return
+	}
+
+	if lineStart && e.column > 1 && e.line+e.lineOffset >= line {
+		e.appendOut(e.options.NewLine, false)
+	}
+
+	for e.line+e.lineOffset < line {
+		e.appendOut(e.options.NewLine, false)
+	}
+
+	if e.line+e.lineOffset > line {
+		if e.column > 1 {
+			e.appendOut(e.options.NewLine, false)
+		}
+
+		directive := "#line " + strconv.Itoa(line)
+
+		if e.file != "" {
+			directive += " \"" + e.file + "\""
+		}
+
+		// the directive sets the number of the line following it
+		e.appendOut(directive+e.options.NewLine, false)
+		e.lineOffset = line - e.line
+	}
+}
+
+// Maps the current output position to the position of the node in source code.
+// Nodes without position, which were not read from source code, are skipped.
+func (e *Emitter) mark(node ast.Node) {
+	pos := node.Pos()
+
+	if pos.Line == 0 {
+		return
+	}
+
+	if n := len(e.mappings); n > 0 && e.mappings[n-1].GeneratedLine == e.line && e.mappings[n-1].GeneratedColumn == e.column {
+		e.mappings = e.mappings[:n-1]
+	}
+
+	e.sourceLine = pos.Line
+	e.mappings = append(e.mappings, sourcemap.Mapping{
+		GeneratedLine:   e.line,
+		GeneratedColumn: e.column,
+		SourceLine:      pos.Line,
+		SourceColumn:    pos.Column})
+}
+
+// Indents the line when pretty printing and nothing was written to it yet.
+func (e *Emitter) appendIndent() {
+	if e.options.Pretty && e.column == 1 {
+		e.appendOut(e.indentation(e.level), false)
+	}
+}
+
+// Appends the output string to current SQF code output.
+// If newLine is true, the string is written as a line of its own when pretty printing.
+func (e *Emitter) appendOut(str string, newLine bool) {
+	if newLine && e.options.Pretty {
+		e.appendIndent()
+		str += e.options.NewLine
+	}
+
+	e.out += str
+
+	for _, c := range str {
+		if c == '\n' {
+			e.line++
+			e.column = 1
+		} else {
+			e.column++
+		}
+	}
+}
+
+// Returns the SQF operator of a binary operation.
+func binaryOperator(n *ast.Binary) string {
+	switch n.Op {
+	case "or":
+		return "||"
+	case "and":
+		return "&&"
+	case "===":
+		return "isEqualTo"
+	case "!==":
+		return "isNotEqualTo"
+	case "==", "!=":
+		// arrays cannot be compared using == and != in SQF,
+		// the types file has no return types, so only arrays declared in place are detected
+		if isArrayLiteral(n.X) || isArrayLiteral(n.Y) {
+			if n.Op == "==" {
+				return "isEqualTo"
+			}
+
+			return "isNotEqualTo"
+		}
+	}
+
+	return n.Op
+}
+
+// Returns the comments written for given node: all comments of a file if kept, otherwise doc comments of functions.
+func comments(node ast.Node, keep bool) []*ast.Comment {
+	if file, ok := node.(*ast.File); ok && keep {
+		return file.Comments
+	}
+
+	docs := make([]*ast.Comment, 0)
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Func:
+			if n.Doc != nil {
+				docs = append(docs, n.Doc)
+			}
+		case *ast.Code:
+			// code within strings is written without comments
+			return false
+		}
+
+		return true
+	})
+
+	return docs
+}
+
+// Returns true if the SQF code of the expression starts with an array declared in place.
+func isArrayLiteral(expr ast.Expr) bool {
+	switch n := expr.(type) {
+	case *ast.Array:
+		return true
+	case *ast.Binary:
+		return isArrayLiteral(n.X)
+	}
+
+	return false
+}
+
+// Returns true if the character is an ASCII letter, like the first character of named operators.
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
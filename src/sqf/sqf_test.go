@@ -0,0 +1,187 @@
+package sqf_test
+
+import (
+	"ast"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"parser"
+	"sqf"
+	"strings"
+	"testing"
+	"tokenizer"
+)
+
+func TestSQFEmit(t *testing.T) {
+	// func foo() { hint(x + 1); }
+	tree := &ast.File{Stmts: []ast.Stmt{
+		&ast.Func{
+			Name: ident("foo"),
+			Body: &ast.Block{Stmts: []ast.Stmt{
+				&ast.ExprStmt{X: &ast.BuiltinCall{
+					Name:  ident("hint"),
+					Type:  ast.UnaryCall,
+					Right: []ast.Expr{&ast.Binary{X: ident("x"), Op: "+", Y: &ast.Literal{Kind: tokenizer.Number, Value: "1"}}},
+				}},
+			}},
+		},
+	}}
+
+	equal(t, sqf.Emit(tree, true), "foo = {\r\n    hint (x + 1);\r\n};\r\n")
+	equal(t, sqf.Emit(tree, false), "foo = {hint (x+1);};")
+}
+
+func TestSQFEmitExpression(t *testing.T) {
+	// a.setVariable("x", [1]) == [] in 'text'
+	expr := &ast.Binary{
+		X: &ast.BuiltinCall{
+			Name:   ident("setVariable"),
+			Type:   ast.BinaryCall,
+			Left:   []ast.Expr{ident("a")},
+			Right:  []ast.Expr{&ast.Literal{Kind: tokenizer.String, Value: "\"x\""}, &ast.Array{Elems: []ast.Expr{ident("1")}}},
+			Method: true,
+		},
+		Op: "==",
+		Y:  &ast.Binary{X: &ast.Array{}, Op: "in", Y: &ast.Literal{Kind: tokenizer.String, Value: "`text`"}},
+	}
+
+	equal(t, sqf.Emit(expr, true), "(a setVariable [\"x\", [1]]) isEqualTo ([] in \"text\")")
+}
+
+func TestSQFEmitMethodArgs(t *testing.T) {
+	// _unit.enableSimulation(_a && _b); _arr.select(_i == 0);
+	method := func(name, left string, arg ast.Expr) ast.Stmt {
+		return &ast.ExprStmt{X: &ast.BuiltinCall{Name: ident(name), Type: ast.BinaryCall, Left: []ast.Expr{ident(left)}, Right: []ast.Expr{arg}, Method: true}}
+	}
+	tree := &ast.Block{Stmts: []ast.Stmt{
+		method("enableSimulation", "_unit", &ast.Binary{X: ident("_a"), Op: "&&", Y: ident("_b")}),
+		method("select", "_arr", &ast.Binary{X: ident("_i"), Op: "==", Y: &ast.Literal{Kind: tokenizer.Number, Value: "0"}}),
+	}}
+
+	equal(t, sqf.Emit(tree, false), "_unit enableSimulation (_a&&_b);_arr select (_i==0);")
+	got, _ := sqf.EmitOptions(tree, sqf.Options{Minify: true})
+	equal(t, got, "_unit enableSimulation(_a&&_b);_arr select(_i==0);")
+}
+
+func TestSQFEmitOptions(t *testing.T) {
+	// if a { foo([1, 2, 3], "text"); }
+	tree := &ast.If{
+		Cond: ident("a"),
+		Then: &ast.Block{Stmts: []ast.Stmt{
+			&ast.ExprStmt{X: &ast.Call{
+				Name: ident("foo"),
+				Args: []ast.Expr{&ast.Array{Elems: []ast.Expr{ident("1"), ident("2"), ident("3")}}, &ast.Literal{Kind: tokenizer.String, Value: "\"text\""}},
+			}},
+		}},
+	}
+
+	got, _ := sqf.EmitOptions(tree, sqf.Options{Pretty: true, Tabs: true, NewLine: sqf.LF})
+	equal(t, got, "if (a) then {\n\t[[1, 2, 3], \"text\"] call foo;\n};\n")
+	got, _ = sqf.EmitOptions(tree, sqf.Options{Pretty: true, Indent: 2, NewLine: sqf.LF, MaxWidth: 20})
+	equal(t, got, "if (a) then {\n  [\n    [1, 2, 3],\n    \"text\"\n  ] call foo;\n};\n")
+	got, _ = sqf.EmitOptions(tree, sqf.Options{MaxWidth: 20})
+	equal(t, got, "if (a) then {[[1,2,3], \"text\"] call foo;};")
+	got, _ = sqf.EmitOptions(tree, sqf.Options{Header: "generated */"})
+	equal(t, got, "/* generated * / */\r\nif (a) then {[[1,2,3], \"text\"] call foo;};")
+}
+
+func TestSQFEmitMap(t *testing.T) {
+	in, _ := os.Open("../../test/tokenizer_switch.asl")
+	defer in.Close()
+	compiler := parser.Compiler{}
+	tree, _ := compiler.ParseAST(tokenizer.NewLexer(in, ""))
+	_, mappings := sqf.EmitMap(tree, true)
+	want := [][4]int{
+		{1, 1, 1, 1}, // switch
+		{2, 5, 2, 5}, // case 1:
+		{3, 9, 3, 9}, // x = 1;
+		{5, 5, 4, 5}, // case 2:
+		{6, 9, 5, 9}, // x = 2;
+		{8, 5, 6, 5}, // default:
+		{9, 9, 7, 9}} // x = 3;
+
+	if len(mappings) != len(want) {
+		t.Fatal("Each statement must be mapped, got:", mappings)
+	}
+
+	for i := range want {
+		if got := mappings[i]; got.GeneratedLine != want[i][0] || got.GeneratedColumn != want[i][1] || got.SourceLine != want[i][2] || got.SourceColumn != want[i][3] {
+			t.Error("Mapping does not match:", mappings[i], want[i])
+		}
+	}
+}
+
+func ident(name string) *ast.Ident {
+	return &ast.Ident{Name: name}
+}
+
+func equal(t *testing.T, got, want string) {
+	if got != want {
+		t.Error("Results do not equal, got:")
+		t.Log(got)
+		t.Log("want:")
+		t.Log(want)
+	}
+}
+
+func TestSQFEmitLines(t *testing.T) {
+	in, _ := os.Open("../../test/sqf_lines.asl")
+	defer in.Close()
+	compiler := parser.Compiler{}
+	tree, _ := compiler.ParseAST(tokenizer.NewLexer(in, "sqf_lines.asl"))
+	got, mappings := sqf.EmitLines(tree)
+	want := "_a = 1;\r\n\r\n\r\nif (_a>0) then {\r\n_a = 2;} else {\r\n\r\n_a = 3;};\r\n\r\n_b = 2;\r\n#line 9 \"sqf_lines.asl\"\r\n#define C 3\r\n_a = _b;"
+	equal(t, got, want)
+
+	// statements are mapped to the same line, except for those behind the #line directive
+	for _, m := range mappings {
+		if m.GeneratedLine != m.SourceLine && m.SourceLine < 9 {
+			t.Error("Statement not on the line of source code:", m)
+		}
+	}
+
+	// the header does not move the code to the next line
+	got, _ = sqf.EmitOptions(tree, sqf.Options{PreserveLines: true, Header: "generated"})
+
+	if !strings.HasPrefix(got, "/* generated */_a = 1;\r\n\r\n\r\nif") {
+		t.Error("Header must be written on the first line of code, got:", got)
+	}
+}
+
+func TestSQFEmitMinify(t *testing.T) {
+	in, _ := os.Open("../../test/sqf_minify.asl")
+	defer in.Close()
+	compiler := parser.Compiler{}
+	tree, _ := compiler.ParseAST(tokenizer.NewLexer(in, ""))
+	got, mappings := sqf.EmitOptions(tree, sqf.Options{Minify: true, Pretty: true})
+	want := `sum={params["_b",["_c",.5]];_a=_c;{_d=_x;_a=(_a+(_d select 0))*16;}forEach(_b);` +
+		`if(!(_a>1e6&&["_verbose"]call isNil))then{_verbose='say "yes"';};return _a+_offset;};_sum=[[1,2],3]call sum;`
+	equal(t, got, want)
+
+	// var _total = _start;
+	if len(mappings) != 8 || mappings[1].GeneratedLine != 1 || mappings[1].GeneratedColumn != 29 || mappings[1].SourceLine != 2 {
+		t.Error("Mappings must point to the minified code, got:", mappings)
+	}
+}
+
+func TestSQFEmitComments(t *testing.T) {
+	code, _ := ioutil.ReadFile("../../test/sqf_comments.asl")
+	lexer := tokenizer.NewLexer(bytes.NewReader(code), "")
+	lexer.EnableTrivia()
+	compiler := parser.Compiler{}
+	tree, _ := compiler.ParseAST(lexer)
+
+	// doc comments are always written
+	got, _ := sqf.EmitOptions(tree, sqf.Options{})
+	equal(t, got, "_x = 1;\r\n/**\n * Adds two numbers.\n */\r\nadd = {params [\"_a\",\"_b\"];return _a+_b;};sub = {params [\"_a\",\"_b\"];return _a-_b;};")
+
+	got, _ = sqf.EmitOptions(tree, sqf.Options{Pretty: true, KeepComments: true, NewLine: sqf.LF})
+	want := "_x = 1; // one\n/**\n * Adds two numbers.\n */\nadd = {\n    params [\"_a\", \"_b\"];\n    // sum\n    return _a + _b; /* done */\n    // end of add\n};\n" +
+		"/* not a doc */\nsub = {\n    params [\"_a\", \"_b\"];\n    return _a - _b;\n};\n"
+	equal(t, got, want)
+
+	// line comments end their line in compact code
+	got, _ = sqf.EmitOptions(tree, sqf.Options{Minify: true, KeepComments: true, NewLine: sqf.LF})
+	want = "_x=1;// one\n/**\n * Adds two numbers.\n */\nadd={params[\"_a\",\"_b\"];// sum\nreturn _a+_b;/* done */// end of add\n};/* not a doc */sub={params[\"_a\",\"_b\"];return _a-_b;};"
+	equal(t, got, want)
+}
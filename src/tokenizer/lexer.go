@@ -0,0 +1,364 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"diagnostic"
+	"io"
+	"unicode/utf8"
+)
+
+// Reads tokens from an io.Reader on demand.
+// The input is scanned in a single pass, comments and whitespace are skipped.
+type Lexer struct {
+	reader      *bufio.Reader
+	file        string
+	offset      int // byte offset of the next character
+	line        int
+	column      int
+	text        []byte  // text of the token currently scanned
+	peeked      []Token // tokens read ahead by Peek
+	trivia      bool    // keep whitespace and comments
+	bom         bool
+	err         error
+	diagnostics []diagnostic.Diagnostic
+}
+
+// Creates a new lexer reading from r.
+// The file name is stored within the tokens, a leading UTF-8 byte order mark is skipped.
+func NewLexer(r io.Reader, file string) *Lexer {
+	l := &Lexer{reader: bufio.NewReader(r), file: file, line: 1, column: 1}
+
+	if b, _ := l.reader.Peek(len(bom)); bytes.Equal(b, bom) {
+		l.reader.Discard(len(bom))
+		l.offset = len(bom)
+		l.bom = true
+	}
+
+	return l
+}
+
+// Keeps whitespace, new lines and comments as trivia within the tokens.
+// Trailing trivia of a token reaches to the end of its line including the new line,
+// everything else is leading trivia of the following token. Must be called before tokens are read.
+func (l *Lexer) EnableTrivia() {
+	l.trivia = true
+}
+
+// Returns the next token and moves on.
+// If the end of input is reached, a token of kind EOF is returned.
+func (l *Lexer) Next() Token {
+	if len(l.peeked) > 0 {
+		token := l.peeked[0]
+		l.peeked = l.peeked[1:]
+		return token
+	}
+
+	return l.scan()
+}
+
+// Returns the token n positions ahead without moving on,
+// Peek(0) returns the token the next call to Next will return.
+func (l *Lexer) Peek(n int) Token {
+	for len(l.peeked) <= n {
+		l.peeked = append(l.peeked, l.scan())
+	}
+
+	return l.peeked[n]
+}
+
+// Returns the first error returned by the underlying reader, except io.EOF.
+func (l *Lexer) Err() error {
+	return l.err
+}
+
+// Returns the problems found in tokens read so far, like unterminated strings or malformed numbers.
+// The lexer does not stop on these, the affected tokens are returned as read.
+func (l *Lexer) Diagnostics() []diagnostic.Diagnostic {
+	return l.diagnostics
+}
+
+// Sets the position of the next character, for code embedded in other source code.
+func (l *Lexer) SetPosition(pos diagnostic.Position) {
+	l.line = pos.Line
+	l.column = pos.Column
+	l.offset = pos.Offset
+	l.bom = false
+}
+
+// Scans the next token from input.
+func (l *Lexer) scan() Token {
+	leading := l.scanTrivia(false)
+
+	if l.bom && l.trivia && l.offset == len(bom) {
+		leading = append([]Trivia{{Whitespace, string(bom)}}, leading...)
+	}
+
+	token := Token{Line: l.line, Column: l.column, Start: l.offset, File: l.file, Leading: leading}
+	l.text = l.text[:0]
+
+	if l.end() {
+		token.Kind = EOF
+		token.End = l.offset
+		return token
+	}
+
+	c, next := l.peek(0), l.peek(1)
+
+	if c == preprocessor {
+		token.Kind = Preprocessor
+		l.scanPreprocessor()
+	} else if c == interpolation && next == '"' {
+		token.Kind = String
+		l.scanInterpolation(token)
+	} else if byteArrayContains(quotes, c) {
+		token.Kind = String
+		l.scanString(token)
+	} else if isDigit(c) || (c == method && isDigit(next)) || c == hex {
+		token.Kind = Number
+		l.scanNumber()
+	} else if byteArrayContains(delimiter, c) || c == method {
+		token.Kind = Operator
+		l.advance()
+	} else {
+		token.Kind = Identifier
+		l.scanIdentifier()
+	}
+
+	token.End = l.offset
+	token.Token = string(l.text)
+
+	if token.Kind == Identifier && stringArrayContains(token.Token) {
+		token.Kind = Keyword
+	} else if token.Kind == Number && !number.MatchString(token.Token) {
+		l.fail(diagnostic.MalformedNumber, "Malformed number '"+token.Token+"'", token)
+	} else if token.Kind == Identifier && !isASCII(token.Token) {
+		// SQF variable and function names are restricted to ASCII
+		l.fail(diagnostic.InvalidIdentifier, "Identifier '"+token.Token+"' contains characters other than ASCII", token)
+	}
+
+	token.Trailing = l.scanTrivia(true)
+	return token
+}
+
+// Skips whitespace, single line comments starting with // (two slashes)
+// and multi line comments with /* ... */ (slash star, star slash).
+// If trivia is enabled, the skipped parts are returned. Trailing trivia stops after the first new line.
+func (l *Lexer) scanTrivia(trailing bool) []Trivia {
+	var trivia []Trivia
+
+	for !l.end() {
+		c, next := l.peek(0), l.peek(1)
+		kind := Whitespace
+		l.text = l.text[:0]
+
+		if byteArrayContains(new_line, c) {
+			if c == '\r' && next == '\n' {
+				l.advance()
+			}
+
+			l.advance()
+			kind = Newline
+		} else if byteArrayContains(whitespace, c) {
+			for !l.end() && byteArrayContains(whitespace, l.peek(0)) && !byteArrayContains(new_line, l.peek(0)) {
+				l.advance()
+			}
+		} else if c == '/' && next == '/' {
+			for !l.end() && !byteArrayContains(new_line, l.peek(0)) {
+				l.advance()
+			}
+
+			kind = Comment
+		} else if c == '/' && next == '*' {
+			l.advance()
+			l.advance()
+
+			for !l.end() && !(l.peek(0) == '*' && l.peek(1) == '/') {
+				l.advance()
+			}
+
+			l.advance()
+			l.advance()
+			kind = Comment
+		} else {
+			break
+		}
+
+		if l.trivia {
+			trivia = append(trivia, Trivia{kind, string(l.text)})
+		}
+
+		if trailing && kind == Newline {
+			break
+		}
+	}
+
+	return trivia
+}
+
+// Reads preprocessor command until end of line.
+func (l *Lexer) scanPreprocessor() {
+	for !l.end() && !byteArrayContains(new_line, l.peek(0)) {
+		l.advance()
+	}
+}
+
+// Reads a string in single quotes, double quotes or backticks.
+// Quotes within are escaped by doubling them, raw strings (backticks) cannot contain backticks.
+func (l *Lexer) scanString(token Token) {
+	quote := l.peek(0)
+	l.advance()
+
+	for {
+		if l.end() {
+			l.fail(diagnostic.UnterminatedString, "Unterminated string", token)
+			return
+		}
+
+		c := l.peek(0)
+		l.advance()
+
+		if c == quote {
+			if quote == raw || l.peek(0) != quote {
+				return
+			}
+
+			l.advance()
+		}
+	}
+}
+
+// Reads an interpolated string like $"{_a} killed {_b}".
+// Expressions in braces can contain strings themselves.
+func (l *Lexer) scanInterpolation(token Token) {
+	depth, quote := 0, byte(0)
+	l.advance()
+	l.advance()
+
+	for {
+		if l.end() {
+			l.fail(diagnostic.UnterminatedString, "Unterminated string", token)
+			return
+		}
+
+		c := l.peek(0)
+		l.advance()
+
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+		} else if depth == 0 && (c == '"' || c == '{' || c == '}') && l.peek(0) == c {
+			// escaped quote or brace
+			l.advance()
+		} else if depth == 0 && c == '"' {
+			return
+		} else if depth > 0 && byteArrayContains(quotes, c) {
+			// strings within expressions, quotes of the text itself are not nested
+			quote = c
+		} else if c == '{' {
+			depth++
+		} else if c == '}' && depth > 0 {
+			depth--
+		}
+	}
+}
+
+// Reads a number. Everything that could belong to a number is read,
+// so that malformed numbers are reported as a whole.
+func (l *Lexer) scanNumber() {
+	l.advance()
+
+	for !l.end() {
+		c := l.peek(0)
+
+		if (c == '+' || c == '-') && exponent.Match(l.text) {
+			// sign of exponent in scientific notation (like 1.5e-3)
+			l.advance()
+		} else if c == method || isIdentifierCharacter(l.peekRune()) {
+			l.advance()
+		} else {
+			return
+		}
+	}
+}
+
+// Reads an identifier or keyword, which ends on whitespace, delimiters,
+// strings, method calls or preprocessor commands.
+func (l *Lexer) scanIdentifier() {
+	for !l.end() {
+		c := l.peek(0)
+
+		if byteArrayContains(whitespace, c) ||
+			byteArrayContains(delimiter, c) ||
+			byteArrayContains(quotes, c) ||
+			c == method ||
+			c == preprocessor {
+			return
+		}
+
+		l.advance()
+	}
+}
+
+// Returns the byte n positions ahead of current one.
+// If no character is left, 0 will be returned.
+func (l *Lexer) peek(n int) byte {
+	b, _ := l.reader.Peek(n + 1)
+
+	if len(b) > n {
+		return b[n]
+	}
+
+	return 0
+}
+
+// Returns the current character.
+func (l *Lexer) peekRune() rune {
+	b, _ := l.reader.Peek(utf8.UTFMax)
+	r, _ := utf8.DecodeRune(b)
+	return r
+}
+
+// Moves to the next character and adds it to the current token.
+// The bytes read are kept as they are, so that invalid UTF-8 is not altered.
+func (l *Lexer) advance() {
+	if l.end() {
+		return
+	}
+
+	b, _ := l.reader.Peek(utf8.UTFMax)
+	r, size := utf8.DecodeRune(b)
+	l.text = append(l.text, b[:size]...)
+	l.reader.Discard(size)
+	l.offset += size
+
+	if r == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+}
+
+// Returns true if the end of input was reached.
+func (l *Lexer) end() bool {
+	_, err := l.reader.Peek(1)
+
+	if err != nil && err != io.EOF && l.err == nil {
+		l.err = err
+	}
+
+	return err != nil
+}
+
+// Reports an error for given token. The end of the token is the current position.
+func (l *Lexer) fail(code, msg string, token Token) {
+	token.Token = string(l.text)
+	l.diagnostics = append(l.diagnostics, diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		File:     l.file,
+		Range:    token.Range(),
+		Message:  msg,
+		Code:     code})
+}
@@ -1,14 +1,56 @@
 package tokenizer
 
 import (
+	"bytes"
+	"diagnostic"
+	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// Kind of a token.
+type Kind int
+
+const (
+	Identifier Kind = iota
+	Keyword
+	Number
+	String
+	Operator
+	Preprocessor
+	EOF
+)
+
+// Kind of trivia.
+type TriviaKind int
+
+const (
+	Whitespace TriviaKind = iota
+	Newline
+	Comment
+)
+
+// Token read from source code.
+// Line and Column start at 1, columns are counted in characters.
+// Start and End are the byte offsets of the token within the source.
+// Leading and Trailing are only set if trivia is enabled on the lexer.
 type Token struct {
-	Token        string
-	Preprocessor bool
-	Line         int
-	Column       int
+	Token    string
+	Kind     Kind
+	Line     int
+	Column   int
+	Start    int
+	End      int
+	File     string
+	Leading  []Trivia
+	Trailing []Trivia
+}
+
+// Whitespace, new lines and comments surrounding a token.
+type Trivia struct {
+	Kind TriviaKind
+	Text string
 }
 
 var (
@@ -36,6 +78,7 @@ var (
 	keywords = []string{
 		"var",
 		"if",
+		"else",
 		"while",
 		"switch",
 		"for",
@@ -50,193 +93,139 @@ var (
 		"catch",
 		"exitwith",
 		"waituntil",
-		"code"}
-
-	whitespace   = []byte{' ', '\n', '\t', '\r'}
-	identifier   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_"
-	preprocessor = byte('#')
-	new_line     = []byte{'\r', '\n'}
+		"code",
+		"in",
+		"and",
+		"or",
+		"not"}
+
+	kindNames = []string{
+		"identifier",
+		"keyword",
+		"number",
+		"string",
+		"operator",
+		"preprocessor",
+		"end of file"}
+
+	whitespace    = []byte{' ', '\n', '\t', '\r'}
+	identifier    = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_"
+	preprocessor  = byte('#')
+	method        = byte('.')
+	interpolation = byte('$')
+	new_line      = []byte{'\r', '\n'}
+	quotes        = []byte{'"', '\'', '`'}
+	raw           = byte('`')
+	hex           = byte('$')
+	bom           = []byte{0xEF, 0xBB, 0xBF}
+
+	// decimal (1, 1.5, .5, 1.5e-3) or hexadecimal (0xFF, $FF) numbers
+	number   = regexp.MustCompile(`^((\d+\.?\d*|\.\d+)([eE][+-]?\d+)?|(0[xX]|\$)[0-9a-fA-F]+)$`)
+	exponent = regexp.MustCompile(`^(\d+\.?\d*|\.\d+)[eE]$`)
 )
 
+// Returns the name of the kind.
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
 // Tokenizes the given byte array into syntax tokens,
 // which can be parsed later.
-func Tokenize(code []byte, doStripSlashes bool) []Token {
-	if doStripSlashes {
-		code = stripSlashes(code)
-	}
+// The code is read as UTF-8, columns are counted in characters.
+// All problems found are returned as diagnostics.
+func Tokenize(code []byte) ([]Token, []diagnostic.Diagnostic) {
+	return TokenizeFile(code, "")
+}
 
-	code = removeComments(code)
+// Tokenizes the given byte array like Tokenize,
+// the file name is stored within the tokens.
+func TokenizeFile(code []byte, file string) ([]Token, []diagnostic.Diagnostic) {
+	lexer := NewLexer(bytes.NewReader(code), file)
 	tokens := make([]Token, 0)
-	token, mask, isstring, line, column := "", false, false, 0, 0
 
-	for i := 0; i < len(code); i++ {
-		c := code[i]
-		column++
-
-		if byteArrayContains(new_line, c) {
-			line++
-			column = 0
-		}
-
-		// string masks (backslash)
-		if c == '\\' && !mask {
-			token += "\\"
-			mask = true
-			continue
-		}
-
-		// string
-		if c == '"' && !mask {
-			token += "\""
-			isstring = !isstring
-			continue
-		}
-
-		if isstring {
-			token += string(c)
-		} else {
-			// preprocessor, delimeter, keyword or variable/expression
-			if c == preprocessor {
-				tokens = append(tokens, preprocessorLine(code, &i, line, column))
-				token = ""
-			} else if byteArrayContains(delimiter, c) {
-				if token != "" {
-					tokens = append(tokens, Token{token, false, line, column})
-				}
-
-				tokens = append(tokens, Token{string(c), false, line, column})
-				token = ""
-			} else if stringArrayContains(strings.ToLower(token)) && !isIdentifierCharacter(c) {
-				tokens = append(tokens, Token{token, false, line, column})
-				token = ""
-			} else if !byteArrayContains(whitespace, c) {
-				token += string(c)
-			}
-		}
-
-		mask = false
+	for token := lexer.Next(); token.Kind != EOF; token = lexer.Next() {
+		tokens = append(tokens, token)
 	}
 
-	return tokens
+	return tokens, lexer.Diagnostics()
 }
 
-// Removes slashes from input code.
-// This is used for the "code" keyword for correct strings in resulting code.
-func stripSlashes(code []byte) []byte {
-	newcode := make([]byte, len(code))
-	j, mask := 0, false
+// Returns the token as written in source code, including trivia.
+func (t Token) Source() string {
+	str := ""
 
-	for i := 0; i < len(code); i++ {
-		c := code[i]
+	for _, trivia := range t.Leading {
+		str += trivia.Text
+	}
 
-		if c == '\\' && !mask {
-			mask = true
-			continue
-		}
+	str += t.Token
 
-		newcode[j] = code[i]
-		mask = false
-		j++
+	for _, trivia := range t.Trailing {
+		str += trivia.Text
 	}
 
-	return newcode
+	return str
 }
 
-// Removes all comments from input byte array.
-// Comments are single line comments, starting with // (two slashes),
-// multi line comments with /* ... */ (slash star, star slash).
-func removeComments(code []byte) []byte {
-	newcode := make([]byte, len(code))
-	j, mask, isstring := 0, false, false
-
-	for i := 0; i < len(code); i++ {
-		c := code[i]
-
-		// do not remove comments from strings
-		if c == '\\' && !mask {
-			mask = true
-		}
-
-		if c == '"' && !mask {
-			isstring = !isstring
-		}
+// Returns the range of source code covered by the token, without trivia.
+func (t Token) Range() diagnostic.Range {
+	end := diagnostic.Position{Line: t.Line, Column: t.Column, Offset: t.Start + len(t.Token)}
 
-		// single/multi line comment
-		if !isstring {
-			if c == '/' && nextChar(code, i) == '/' {
-				i = skipSingleLineComment(code, i+1)
-				continue
-			} else if c == '/' && nextChar(code, i) == '*' {
-				i = skipMultiLineComment(code, i+1)
-				continue
-			}
+	for _, c := range t.Token {
+		if c == '\n' {
+			end.Line++
+			end.Column = 1
+		} else {
+			end.Column++
 		}
-
-		newcode[j] = c
-		j++
-		mask = false
 	}
 
-	return newcode[:j]
+	return diagnostic.Range{
+		Start: diagnostic.Position{Line: t.Line, Column: t.Column, Offset: t.Start},
+		End:   end}
 }
 
-// Reads preprocessor command until end of line
-func preprocessorLine(code []byte, i *int, lineNr, column int) Token {
-	c := byte('0')
-	var line string
-
-	for *i < len(code) {
-		c = code[*i]
-
-		if byteArrayContains(new_line, c) {
-			break
-		}
+// Returns true if the token is a string literal.
+func IsString(token string) bool {
+	return token != "" && byteArrayContains(quotes, token[0])
+}
 
-		line += string(c)
-		(*i)++
+// Returns the content of a string literal.
+// Doubled quotes within are reduced to single ones, raw strings are returned as they are.
+func Unquote(str string) string {
+	if len(str) < 2 {
+		return ""
 	}
 
-	// read all new line characters (\r and \n)
-	c = code[*i]
+	quote := str[0]
+	str = str[1 : len(str)-1]
 
-	for byteArrayContains(new_line, c) {
-		(*i)++
-		c = code[*i]
+	if quote == raw {
+		return str
 	}
 
-	(*i)-- // for will count up 1, so subtract it here
-
-	return Token{line, true, lineNr, column}
+	return strings.Replace(str, string(quote)+string(quote), string(quote), -1)
 }
 
-// Returns the next character in code starting at i.
-// If no character is left, '0' will be returned.
-func nextChar(code []byte, i int) byte {
-	i++
-
-	if i < len(code) {
-		return code[i]
-	}
-
-	return '0'
+// Returns the string as SQF string literal in double quotes.
+func Quote(str string) string {
+	return "\"" + strings.Replace(str, "\"", "\"\"", -1) + "\""
 }
 
-// Used to skip a line if a single line comment was found.
-func skipSingleLineComment(code []byte, i int) int {
-	for i < len(code) && code[i] != '\n' {
-		i++
-	}
-
-	return i
+// Checks if a character is a decimal digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
 }
 
-// Used to skip a block of characters if a multi line comment was found
-func skipMultiLineComment(code []byte, i int) int {
-	for i < len(code) && !(code[i] == '*' && nextChar(code, i) == '/') {
-		i++
+// Checks if a string consists of ASCII characters only.
+func isASCII(str string) bool {
+	for i := 0; i < len(str); i++ {
+		if str[i] >= utf8.RuneSelf {
+			return false
+		}
 	}
 
-	return i + 1
+	return true
 }
 
 // Checks if a byte array (string) contains a delimeter.
@@ -262,12 +251,11 @@ func stringArrayContains(needle string) bool {
 }
 
 // Checks if a character is allowed for identifiers.
-func isIdentifierCharacter(c byte) bool {
-	for i := range identifier {
-		if identifier[i] == c {
-			return true
-		}
+// Next to the ASCII characters, all unicode letters and digits are allowed.
+func isIdentifierCharacter(c rune) bool {
+	if c >= utf8.RuneSelf {
+		return unicode.IsLetter(c) || unicode.IsDigit(c)
 	}
 
-	return false
+	return strings.ContainsRune(identifier, c)
 }
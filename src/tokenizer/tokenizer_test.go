@@ -1,7 +1,9 @@
 package tokenizer_test
 
 import (
+	"diagnostic"
 	"io/ioutil"
+	"strings"
 	"testing"
 	"tokenizer"
 )
@@ -94,15 +96,199 @@ func TestTokenizerPreprocessor(t *testing.T) {
 	compareTokens(t, &got, &want)
 }
 
-func TestTokenizerMask(t *testing.T) {
-	got := getTokens(t, "../../test/tokenizer_mask.asl")
-	want := []string{"var", "x", "=", "\"Hello \\\"World\\\"\"", ";",
-		"var", "y", "=", "code", "(", "\"var z = \\\"Hello \\\\\"World\\\\\"\\\";\"", ")", ";"}
+func TestTokenizerString(t *testing.T) {
+	got := getTokens(t, "../../test/tokenizer_string.asl")
+	want := []string{"var", "a", "=", "\"Hello \"\"World\"\"\"", ";",
+		"var", "b", "=", "'single ''quoted'''", ";",
+		"var", "c", "=", "\"\\a3\\ui_f\\data\\map\\markers\\military\\dot_ca.paa\"", ";",
+		"var", "d", "=", "`raw \"string\" // no comment`", ";",
+		"var", "e", "=", "\"multi\nline\"", ";",
+		"var", "f", "=", "code", "(", "\"var z = \"\"Hello\"\";\"", ")", ";"}
 
 	compareLength(t, &got, &want)
 	compareTokens(t, &got, &want)
 }
 
+func TestTokenizerMethodCall(t *testing.T) {
+	got := getTokens(t, "../../test/tokenizer_method_call.asl")
+	want := []string{"var", "x", "=", "someUnit", ".", "addItem", "(", "1.23", ")", ";"}
+
+	compareLength(t, &got, &want)
+	compareTokens(t, &got, &want)
+}
+
+func TestTokenizerInterpolation(t *testing.T) {
+	got := getTokens(t, "../../test/parser_interpolation.asl")
+	want := []string{"hint", "(", "$\"{_a} killed {_b}\"", ")", ";",
+		"var", "_text", "=", "$\"{name(_unit)} has {_count*100}% health {{ok}}\"", ";",
+		"var", "_nested", "=", "$\"{_a + \"x\"}\"", ";",
+		"var", "_quoted", "=", "$\"say \"\"{_a + 'x'}\"\"\"", ";",
+		"var", "_apostrophe", "=", "$\"it's {_a}\"", ";",
+		"var", "_leading", "=", "$\"'{_a}\"", ";"}
+
+	compareLength(t, &got, &want)
+	compareTokens(t, &got, &want)
+}
+
+func TestTokenizerNumber(t *testing.T) {
+	got := getTokens(t, "../../test/tokenizer_number.asl")
+	want := []string{"var", "x", "=", "1.5e-3", "+", "2E+10", "-", ".5", "*", "0xFF", "/", "$1f", "-", "12", ";"}
+
+	compareLength(t, &got, &want)
+	compareTokens(t, &got, &want)
+}
+
+func TestTokenizerMalformedNumber(t *testing.T) {
+	for _, number := range []string{"1.2.3", "0xZZ", "12abc", "1e", "$"} {
+		_, diagnostics := tokenizer.Tokenize([]byte("var x = " + number + ";"))
+
+		if len(diagnostics) != 1 || diagnostics[0].Code != diagnostic.MalformedNumber {
+			t.Error("Malformed number must fail: " + number)
+		}
+	}
+}
+
+func TestTokenizerLexerDiagnostics(t *testing.T) {
+	lexer := tokenizer.NewLexer(strings.NewReader("var x = 1.2.3;\nvar y = \"open;"), "test.asl")
+
+	for lexer.Next().Kind != tokenizer.EOF {
+	}
+
+	diagnostics := lexer.Diagnostics()
+
+	if len(diagnostics) != 2 {
+		t.Fatal("Lexer must report malformed number and unterminated string")
+	}
+
+	if d := diagnostics[0]; d.Code != diagnostic.MalformedNumber || d.Range.Start.Column != 9 || d.Range.End.Column != 14 || d.File != "test.asl" {
+		t.Error("Malformed number not reported correctly:", d)
+	}
+
+	if d := diagnostics[1]; d.Code != diagnostic.UnterminatedString || d.Range.Start.Line != 2 || d.Range.End.Offset != 29 {
+		t.Error("Unterminated string not reported correctly:", d)
+	}
+}
+
+func TestTokenizerNonASCIIIdentifier(t *testing.T) {
+	lexer := tokenizer.NewLexer(strings.NewReader("var größe = \"größe\";"), "test.asl")
+
+	for lexer.Next().Kind != tokenizer.EOF {
+	}
+
+	diagnostics := lexer.Diagnostics()
+
+	if len(diagnostics) != 1 {
+		t.Fatal("Lexer must report the identifier, but not the string, got:", diagnostics)
+	}
+
+	if d := diagnostics[0]; d.Code != diagnostic.InvalidIdentifier || d.Range.Start.Column != 5 || d.Range.End.Column != 10 {
+		t.Error("Identifier not reported correctly:", d)
+	}
+}
+
+func TestTokenizerUTF8(t *testing.T) {
+	got := getTokens(t, "../../test/tokenizer_utf8.asl")
+	want := []string{"var", "gruss", "=", "\"Grüße, Привет!\"", ";", "hint", "(", "$\"{gruss} ёж\"", ")", ";"}
+
+	compareLength(t, &got, &want)
+	compareTokens(t, &got, &want)
+
+	if got[4].Column != 29 {
+		t.Errorf("Column must be counted in characters, expected 29 but was %d", got[4].Column)
+	}
+}
+
+func TestTokenizerKindAndPosition(t *testing.T) {
+	code, err := ioutil.ReadFile("../../test/tokenizer_kind.asl")
+
+	if err != nil {
+		t.Fatal("Could not read test file")
+	}
+
+	got, _ := tokenizer.TokenizeFile(code, "tokenizer_kind.asl")
+	want := []struct {
+		kind                     tokenizer.Kind
+		line, column, start, end int
+	}{
+		{tokenizer.Keyword, 1, 1, 0, 3},
+		{tokenizer.Identifier, 1, 5, 4, 6},
+		{tokenizer.Operator, 1, 8, 7, 8},
+		{tokenizer.String, 1, 10, 9, 12},
+		{tokenizer.Operator, 1, 14, 13, 14},
+		{tokenizer.Number, 1, 16, 15, 16},
+		{tokenizer.Operator, 1, 17, 16, 17},
+		{tokenizer.Preprocessor, 3, 9, 46, 55},
+		{tokenizer.Identifier, 4, 1, 56, 59},
+		{tokenizer.Operator, 4, 4, 59, 60},
+		{tokenizer.Identifier, 4, 5, 60, 62},
+		{tokenizer.Operator, 4, 7, 62, 63},
+		{tokenizer.Operator, 4, 8, 63, 64},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d tokens, got %d", len(want), len(got))
+	}
+
+	for i, w := range want {
+		g := got[i]
+
+		if g.Kind != w.kind || g.Line != w.line || g.Column != w.column || g.Start != w.start || g.End != w.end {
+			t.Errorf("Token '%s' is %s at %d:%d (%d-%d), expected %s at %d:%d (%d-%d)",
+				g.Token, g.Kind, g.Line, g.Column, g.Start, g.End, w.kind, w.line, w.column, w.start, w.end)
+		}
+
+		if g.Token != string(code[g.Start:g.End]) {
+			t.Errorf("Token '%s' does not match source range '%s'", g.Token, string(code[g.Start:g.End]))
+		}
+
+		if g.File != "tokenizer_kind.asl" {
+			t.Error("File name not set for token " + g.Token)
+		}
+	}
+}
+
+func TestLexerPeek(t *testing.T) {
+	lexer := tokenizer.NewLexer(strings.NewReader("var x = 1; // comment"), "")
+
+	if lexer.Peek(2).Token != "=" || lexer.Peek(0).Token != "var" {
+		t.Error("Peek must return tokens ahead without moving on")
+	}
+
+	want := []string{"var", "x", "=", "1", ";"}
+
+	for _, w := range want {
+		if got := lexer.Next(); got.Token != w {
+			t.Error("Tokens do not match: " + got.Token + " != " + w)
+		}
+	}
+
+	if lexer.Next().Kind != tokenizer.EOF || lexer.Peek(3).Kind != tokenizer.EOF {
+		t.Error("Lexer must return EOF at end of input")
+	}
+}
+
+func TestLexerTrivia(t *testing.T) {
+	lexer := tokenizer.NewLexer(strings.NewReader("// doc\n  x = 1; // x\n"), "")
+	lexer.EnableTrivia()
+	x := lexer.Next()
+
+	if len(x.Leading) != 3 || x.Leading[0].Kind != tokenizer.Comment || x.Leading[1].Kind != tokenizer.Newline || x.Leading[2].Text != "  " {
+		t.Error("Leading trivia must contain comment, new line and indentation")
+	}
+
+	lexer.Next()
+	lexer.Next()
+	semicolon := lexer.Next()
+
+	if len(semicolon.Trailing) != 3 || semicolon.Trailing[1].Text != "// x" || semicolon.Trailing[2].Kind != tokenizer.Newline {
+		t.Error("Trailing trivia must contain whitespace, comment and new line")
+	}
+
+	if eof := lexer.Next(); eof.Kind != tokenizer.EOF || eof.Source() != "" {
+		t.Error("End of file must not hold trivia")
+	}
+}
+
 func compareLength(t *testing.T, got *[]tokenizer.Token, want *[]string) {
 	if len(*got) != len(*want) {
 		t.Error("Length of tokens got and expected tokens not equal, was:")
@@ -139,5 +325,11 @@ func getTokens(t *testing.T, file string) []tokenizer.Token {
 		t.FailNow()
 	}
 
-	return tokenizer.Tokenize(code, false)
+	tokens, diagnostics := tokenizer.Tokenize(code)
+
+	for _, d := range diagnostics {
+		t.Error(d)
+	}
+
+	return tokens
 }
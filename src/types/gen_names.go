@@ -0,0 +1,91 @@
+//go:build ignore
+// +build ignore
+
+// Generates names.go from the build in functions listed by tools/asl.xml.
+// Run go generate within src/types after changing the list.
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"strings"
+)
+
+const (
+	in        = "../../tools/asl.xml"
+	out       = "names.go"
+	lineWidth = 120
+)
+
+var functions = regexp.MustCompile(`<Keywords name="Keywords2">([^<]*)</Keywords>`)
+
+func main() {
+	xml, err := ioutil.ReadFile(in)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	match := functions.FindSubmatch(xml)
+
+	if match == nil {
+		log.Fatal("No build in functions found in " + in)
+	}
+
+	var code bytes.Buffer
+	code.WriteString("// Code generated by gen_names.go from tools/asl.xml. DO NOT EDIT.\n\n")
+	code.WriteString("package types\n\nimport (\n\t\"strings\"\n)\n\n")
+	code.WriteString("// Names of build in functions as spelled on the community wiki, like getPos.\n")
+	code.WriteString("var wikiNames = strings.Fields(`\n")
+	line := ""
+
+	for _, name := range names(string(match[1])) {
+		if line != "" && len(line)+1+len(name) > lineWidth {
+			code.WriteString(line + "\n")
+			line = ""
+		}
+
+		if line != "" {
+			line += " "
+		}
+
+		line += name
+	}
+
+	code.WriteString(line + "\n`)\n")
+	formatted, err := format.Source(code.Bytes())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(out, formatted, 0666); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Returns the names of the list without duplicates. The list writes diag_ functions like "diag log".
+func names(list string) []string {
+	fields := strings.Fields(list)
+	names := make([]string, 0, len(fields))
+	listed := make(map[string]bool)
+
+	for i := 0; i < len(fields); i++ {
+		name := fields[i]
+
+		if name == "diag" && i+1 < len(fields) {
+			i++
+			name = "diag_" + fields[i]
+		}
+
+		if !listed[name] {
+			listed[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
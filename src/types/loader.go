@@ -2,6 +2,7 @@ package types
 
 import (
 	"io/ioutil"
+	"sort"
 	"strings"
 )
 
@@ -16,19 +17,24 @@ const (
 	UNARY  = 3
 	BINARY = 4
 
-	win_new_line  = "\r\n"
-	unix_new_line = "\n"
+	win_new_line    = "\r\n"
+	unix_new_line   = "\n"
+	max_suggestions = 3
 )
 
 type FunctionType struct {
 	Name      string
-	Type      int // one of the constants NULL, UNARY, BINARY
+	Canonical string // name as spelled on the wiki, like getPos, empty if unknown
+	Type      int    // one of the constants NULL, UNARY, BINARY
 	ArgsLeft  int
 	ArgsRight int // number of args on left side for binary functions
 }
 
 var functions []FunctionType
 
+// canonical spelling of build in functions by lower case name
+var spellings map[string]string
+
 // Returns function type information by name.
 // If not found, the parameter will be nil.
 func GetFunction(name string) *FunctionType {
@@ -43,6 +49,107 @@ func GetFunction(name string) *FunctionType {
 	return nil
 }
 
+// Returns the name as spelled on the wiki if known, otherwise as listed by supportInfo.
+func (f *FunctionType) spelling() string {
+	if f.Canonical != "" {
+		return f.Canonical
+	}
+
+	return f.Name
+}
+
+// Returns names of build in functions similar to the given one, the most similar first.
+// Used to suggest build in functions for misspelled names.
+func Suggest(name string) []string {
+	name = strings.ToLower(name)
+	suggestions := make([]string, 0)
+
+	// short names are similar to too many functions
+	if len(name) < 4 {
+		return suggestions
+	}
+
+	maxDistance := 1
+
+	if len(name) > 5 {
+		maxDistance = 2
+	}
+
+	distances := make(map[string]int)
+
+	for _, function := range functions {
+		if _, ok := distances[function.Name]; ok {
+			continue
+		}
+
+		distance := editDistance(name, function.Name)
+		distances[function.Name] = distance
+
+		if distance > 0 && distance <= maxDistance {
+			suggestions = append(suggestions, function.spelling())
+		}
+	}
+
+	// distances are stored by lower case name, while suggestions are spelled as on the wiki
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := strings.ToLower(suggestions[i]), strings.ToLower(suggestions[j])
+
+		if distances[a] != distances[b] {
+			return distances[a] < distances[b]
+		}
+
+		return a < b
+	})
+
+	if len(suggestions) > max_suggestions {
+		suggestions = suggestions[:max_suggestions]
+	}
+
+	return suggestions
+}
+
+// Returns the number of characters to insert, delete, replace or swap to turn a into b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = minInt(minInt(d[i-1][j]+1, d[i][j-1]+1), d[i-1][j-1]+cost)
+
+			// swapped characters
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
 // Loads type information from file.
 // The format is specified by 'supportInfo' command: https://community.bistudio.com/wiki/supportInfo
 func LoadTypes(path string) error {
@@ -54,11 +161,27 @@ func LoadTypes(path string) error {
 
 	data := strings.Replace(win_new_line, unix_new_line, string(content), -1) // make this work on windows and unix
 	functions = make([]FunctionType, 0)
+	loadSpellings()
 	parseTypes(data)
 
 	return nil
 }
 
+// Loads the canonical spellings generated from tools/asl.xml.
+//
+//go:generate go run gen_names.go
+func loadSpellings() {
+	if spellings != nil {
+		return
+	}
+
+	spellings = make(map[string]string)
+
+	for _, name := range wikiNames {
+		spellings[strings.ToLower(name)] = name
+	}
+}
+
 func parseTypes(content string) {
 	lines := strings.Split(content, unix_new_line)
 
@@ -79,7 +202,7 @@ func parseTypes(content string) {
 
 func parseNullFunction(line string) {
 	parts := getParts(line)
-	functions = append(functions, FunctionType{parts[0], NULL, 0, 0})
+	functions = append(functions, FunctionType{parts[0], spellings[strings.ToLower(parts[0])], NULL, 0, 0})
 }
 
 func parseUnaryFunction(line string) {
@@ -97,7 +220,7 @@ func parseUnaryFunction(line string) {
 		argsCount = len(args) - getNaNArgs(args)
 	}
 
-	functions = append(functions, FunctionType{parts[0], UNARY, argsCount, 0})
+	functions = append(functions, FunctionType{parts[0], spellings[strings.ToLower(parts[0])], UNARY, argsCount, 0})
 }
 
 func parseBinaryFunction(line string) {
@@ -121,7 +244,7 @@ func parseBinaryFunction(line string) {
 		argsRightCount = len(argsRight) - getNaNArgs(argsRight)
 	}
 
-	functions = append(functions, FunctionType{parts[1], BINARY, argsLeftCount, argsRightCount})
+	functions = append(functions, FunctionType{parts[1], spellings[strings.ToLower(parts[1])], BINARY, argsLeftCount, argsRightCount})
 }
 
 func getParts(line string) []string {
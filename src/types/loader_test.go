@@ -1,6 +1,9 @@
 package types_test
 
 import (
+	"io/ioutil"
+	"regexp"
+	"strings"
 	"testing"
 	"types"
 )
@@ -16,3 +19,71 @@ func TestTypesGetFunction(t *testing.T) {
 		t.Error("Function 'hint' not found in type list")
 	}
 }
+
+func TestTypesSuggest(t *testing.T) {
+	if err := types.LoadTypes("../../test/types"); err != nil {
+		t.Error(err)
+	}
+
+	if suggestions := types.Suggest("hnit"); len(suggestions) == 0 || suggestions[0] != "hint" {
+		t.Error("Function 'hint' must be suggested for 'hnit'")
+	}
+
+	if suggestions := types.Suggest("hint"); len(suggestions) != 0 && suggestions[0] == "hint" {
+		t.Error("Exact match must not be suggested")
+	}
+
+	// the most similar first, others in alphabetical order
+	if suggestions := types.Suggest("getPoss"); len(suggestions) != 3 || suggestions[0] != "getPos" || suggestions[1] != "getMass" || suggestions[2] != "getPosASL" {
+		t.Error("Suggestions must be ordered by similarity, got:", suggestions)
+	}
+
+	if len(types.Suggest("foo")) != 0 {
+		t.Error("Short names must not get suggestions")
+	}
+}
+
+func TestTypesCanonical(t *testing.T) {
+	if err := types.LoadTypes("../../test/types"); err != nil {
+		t.Error(err)
+	}
+
+	if function := types.GetFunction("GETPOS"); function == nil || function.Canonical != "getPos" {
+		t.Error("Function 'getpos' must be spelled 'getPos'")
+	}
+
+	if function := types.GetFunction("diag_log"); function == nil || function.Canonical != "diag_log" {
+		t.Error("Function 'diag_log' must be spelled 'diag_log'")
+	}
+}
+
+func TestTypesNamesGenerated(t *testing.T) {
+	xml, errXML := ioutil.ReadFile("../../tools/asl.xml")
+	names, errNames := ioutil.ReadFile("names.go")
+
+	if errXML != nil || errNames != nil {
+		t.Fatal("Could not read function lists")
+	}
+
+	listed := make(map[string]bool)
+
+	for _, name := range strings.Fields(string(names)) {
+		listed[name] = true
+	}
+
+	list := regexp.MustCompile(`<Keywords name="Keywords2">([^<]*)</Keywords>`).FindSubmatch(xml)
+	fields := strings.Fields(string(list[1]))
+
+	for i := 0; i < len(fields); i++ {
+		name := fields[i]
+
+		if name == "diag" {
+			i++
+			name = "diag_" + fields[i]
+		}
+
+		if !listed[name] {
+			t.Fatal("Function '" + name + "' of tools/asl.xml is missing, run go generate in src/types")
+		}
+	}
+}